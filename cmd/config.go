@@ -0,0 +1,247 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// configFileName is looked up in the home directory (global) and in the working directory (per-directory)
+const configFileName = ".git-releaser.yaml"
+
+// repoConfig is a single entry of the repos list of the config file.
+// Every unset field falls back to the global settings.
+type repoConfig struct {
+	URL            string  `mapstructure:"url"`
+	Source         *string `mapstructure:"source"`
+	Target         *string `mapstructure:"target"`
+	Tag            *bool   `mapstructure:"tag"`
+	Branch         *bool   `mapstructure:"branch"`
+	NextVersion    *string `mapstructure:"nextversion"`
+	Version        *string `mapstructure:"version"`
+	Line           *string `mapstructure:"line"`
+	Scheme         *string `mapstructure:"scheme"`
+	CalverFormat   *string `mapstructure:"calver-format"`
+	BranchTemplate *string `mapstructure:"branch-template"`
+	TagTemplate    *string `mapstructure:"tag-template"`
+	Granularity    *string `mapstructure:"branch-granularity"`
+	Username       *string `mapstructure:"username"`
+	PAT            *string `mapstructure:"pat"`
+	SSHKey         *string `mapstructure:"ssh-key"`
+	SSHKeyPassword *string `mapstructure:"ssh-key-password"`
+	PreRelease     *string `mapstructure:"pre-release"`
+	PostRelease    *string `mapstructure:"post-release"`
+	// Groups allow selecting a subset of the repos using --group
+	Groups []string `mapstructure:"groups"`
+}
+
+// configFiles returns the config files to read, later files override earlier ones
+func configFiles() []string {
+	if cfgFile != "" {
+		return []string{cfgFile}
+	}
+	var files []string
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, configFileName))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		local := filepath.Join(wd, configFileName)
+		if len(files) == 0 || files[0] != local {
+			files = append(files, local)
+		}
+	}
+	return files
+}
+
+// readConfigFiles merges the global and the per-directory config file (or the file passed by --config) into v.
+// All settings except the repos list become defaults for the flags, the repos list is returned separately.
+func readConfigFiles(v *viper.Viper, files []string, explicit bool) ([]repoConfig, error) {
+	fileConfig := viper.New()
+	for _, f := range files {
+		if _, err := os.Stat(f); err != nil && !explicit {
+			continue
+		}
+		fileConfig.SetConfigFile(f)
+		if err := fileConfig.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("could not read config file %s: %w", f, err)
+		}
+		log.Debug().Msgf("Using config file %s", f)
+	}
+
+	settings := fileConfig.AllSettings()
+	delete(settings, "repos")
+	if err := v.MergeConfigMap(settings); err != nil {
+		return nil, err
+	}
+	return parseRepoConfigs(fileConfig.Get("repos"))
+}
+
+// parseRepoConfigs accepts a list of plain repo urls and / or maps with per repo settings
+func parseRepoConfigs(raw interface{}) ([]repoConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("repos must be a list, got %T", raw)
+	}
+	var configs []repoConfig
+	for i, e := range entries {
+		var rc repoConfig
+		switch v := e.(type) {
+		case string:
+			rc.URL = v
+		default:
+			if err := mapstructure.Decode(v, &rc); err != nil {
+				return nil, fmt.Errorf("invalid repos entry %d: %w", i+1, err)
+			}
+		}
+		if rc.URL == "" {
+			return nil, fmt.Errorf("repos entry %d has no url", i+1)
+		}
+		if err := rc.validate(); err != nil {
+			return nil, fmt.Errorf("repos entry %d: %w", i+1, err)
+		}
+		configs = append(configs, rc)
+	}
+	return configs, nil
+}
+
+// validate checks the values of rc which can't be checked by decoding
+func (rc repoConfig) validate() error {
+	if rc.NextVersion != nil {
+		if _, ok := nextVersions[strings.ToUpper(*rc.NextVersion)]; !ok {
+			return fmt.Errorf("invalid nextversion %q of %s, possible values: PATCH, MINOR, MAJOR", *rc.NextVersion, rc.URL)
+		}
+	}
+	if rc.Line != nil && *rc.Line != "" {
+		if _, err := version.ParseLine(*rc.Line); err != nil {
+			return fmt.Errorf("%w of %s", err, rc.URL)
+		}
+	}
+	// the version is validated on release, as it depends on the scheme
+	if rc.Granularity != nil {
+		if _, err := remote.ParseGranularity(*rc.Granularity); err != nil {
+			return fmt.Errorf("%w of %s", err, rc.URL)
+		}
+	}
+	if rc.BranchTemplate != nil || rc.TagTemplate != nil {
+		if err := rc.apply(releaseOptions{}, func(string) bool { return false }).naming.Validate(); err != nil {
+			return fmt.Errorf("%w of %s", err, rc.URL)
+		}
+	}
+	if rc.Scheme != nil || rc.CalverFormat != nil || rc.Granularity != nil {
+		if err := rc.apply(releaseOptions{}, func(string) bool { return false }).validateScheme(); err != nil {
+			return fmt.Errorf("%w of %s", err, rc.URL)
+		}
+	}
+	return nil
+}
+
+// apply overrides opts with all settings of rc, which haven't been set explicitly on the command line
+func (rc repoConfig) apply(opts releaseOptions, flagChanged func(string) bool) releaseOptions {
+	set := func(name string, isSet bool) bool {
+		return isSet && !flagChanged(name)
+	}
+	if set("source", rc.Source != nil) {
+		opts.sourceBranch = *rc.Source
+	}
+	if set("target", rc.Target != nil) {
+		opts.targetBranch = *rc.Target
+	}
+	if set("tag", rc.Tag != nil) {
+		opts.createTag = *rc.Tag
+	}
+	if set("branch", rc.Branch != nil) {
+		opts.createBranch = *rc.Branch
+	}
+	if set("nextversion", rc.NextVersion != nil) {
+		// the value has been validated when the config was read
+		opts.nextVersion, _ = setNextVersion(*rc.NextVersion)
+	}
+	if set("version", rc.Version != nil) {
+		opts.version = *rc.Version
+	}
+	if set("line", rc.Line != nil) {
+		opts.line = *rc.Line
+	}
+	if set("scheme", rc.Scheme != nil) {
+		opts.scheme = *rc.Scheme
+	}
+	if set("calver-format", rc.CalverFormat != nil) {
+		opts.calverFormat = *rc.CalverFormat
+	}
+	if set("branch-template", rc.BranchTemplate != nil) {
+		opts.naming.BranchTemplate = *rc.BranchTemplate
+	}
+	if set("tag-template", rc.TagTemplate != nil) {
+		opts.naming.TagTemplate = *rc.TagTemplate
+	}
+	if set("branch-granularity", rc.Granularity != nil) {
+		// the value has been validated when the config was read
+		opts.naming.Granularity, _ = remote.ParseGranularity(*rc.Granularity)
+	}
+	if set("username", rc.Username != nil) {
+		opts.username = *rc.Username
+	}
+	if set("pat", rc.PAT != nil) {
+		opts.pat = *rc.PAT
+	}
+	if set("ssh-key", rc.SSHKey != nil) {
+		opts.sshKey = *rc.SSHKey
+	}
+	if set("ssh-key-password", rc.SSHKeyPassword != nil) {
+		opts.sshKeyPassword = *rc.SSHKeyPassword
+	}
+	if set("pre-release", rc.PreRelease != nil) {
+		opts.preReleaseHook = *rc.PreRelease
+	}
+	if set("post-release", rc.PostRelease != nil) {
+		opts.postReleaseHook = *rc.PostRelease
+	}
+	return opts
+}
+
+// releaseTargets combines every repo url with its release options,
+// the overrides of all configs with the same url are applied in order and only the freezes of the groups of the repo are kept
+func releaseTargets(repoURLs []string, opts releaseOptions, configs []repoConfig, flagChanged func(string) bool) []releaseTarget {
+	byURL := make(map[string][]repoConfig, len(configs))
+	for _, rc := range configs {
+		byURL[rc.URL] = append(byURL[rc.URL], rc)
+	}
+	targets := make([]releaseTarget, 0, len(repoURLs))
+	for _, r := range repoURLs {
+		o := opts
+		var groups []string
+		for _, rc := range byURL[r] {
+			o = rc.apply(o, flagChanged)
+			groups = append(groups, rc.Groups...)
+		}
+		o.freezes = freezesFor(opts.freezes, groups)
+		targets = append(targets, releaseTarget{repoURL: r, opts: o})
+	}
+	return targets
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	name := filepath.Join(dir, configFileName)
+	assert.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	return name
+}
+
+func Test_readConfigFiles(t *testing.T) {
+	global := writeConfig(t, t.TempDir(), `
+source: develop
+target: releases
+repos:
+  - git@github.com:fhopfensperger/a.git
+`)
+	local := writeConfig(t, t.TempDir(), `
+target: release
+nextversion: MINOR
+repos:
+  - git@github.com:fhopfensperger/b.git
+  - url: git@github.com:fhopfensperger/c.git
+    source: main
+    tag: true
+    branch: false
+    nextversion: MAJOR
+    tag-template: "app-{{.Version}}"
+    ssh-key: /keys/c
+`)
+	v := viper.New()
+
+	configs, err := readConfigFiles(v, []string{global, local, filepath.Join(t.TempDir(), configFileName)}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "develop", v.GetString("source"))
+	assert.Equal(t, "release", v.GetString("target"))
+	assert.Equal(t, "MINOR", v.GetString("nextversion"))
+	assert.Len(t, configs, 2)
+	assert.Equal(t, "git@github.com:fhopfensperger/b.git", configs[0].URL)
+	assert.Nil(t, configs[0].Source)
+	assert.Equal(t, "git@github.com:fhopfensperger/c.git", configs[1].URL)
+	assert.Equal(t, "main", *configs[1].Source)
+	assert.Equal(t, true, *configs[1].Tag)
+	assert.Equal(t, false, *configs[1].Branch)
+	assert.Equal(t, "app-{{.Version}}", *configs[1].TagTemplate)
+	assert.Equal(t, "/keys/c", *configs[1].SSHKey)
+}
+
+func Test_readConfigFiles_explicit_missing(t *testing.T) {
+	_, err := readConfigFiles(viper.New(), []string{filepath.Join(t.TempDir(), "missing.yaml")}, true)
+	assert.Error(t, err)
+}
+
+func Test_parseRepoConfigs_invalid(t *testing.T) {
+	_, err := parseRepoConfigs("git@github.com:fhopfensperger/a.git")
+	assert.Error(t, err)
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"source": "main"}})
+	assert.Error(t, err)
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "tag": "not-a-bool"}})
+	assert.Error(t, err)
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "nextversion": "MAJRO"}})
+	assert.ErrorContains(t, err, "MAJRO")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "scheme": "romver"}})
+	assert.ErrorContains(t, err, "romver")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "scheme": "calver", "calver-format": "MICRO.YYYY"}})
+	assert.ErrorContains(t, err, "MICRO.YYYY")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "line": "1.x"}})
+	assert.ErrorContains(t, err, "1.x")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "tag-template": "{{.Version}"}})
+	assert.ErrorContains(t, err, "tag")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "branch-granularity": "daily"}})
+	assert.ErrorContains(t, err, "daily")
+
+	_, err = parseRepoConfigs([]interface{}{map[string]interface{}{"url": "a", "branch-granularity": "minor", "scheme": "calver"}})
+	assert.ErrorContains(t, err, "--branch-granularity")
+}
+
+func Test_releaseTargets(t *testing.T) {
+	source := "develop"
+	tag := true
+	bump := "MAJOR"
+	branchTemplate := "{{.Target}}-{{.Number}}"
+	preRelease := "make verify"
+	version := "3.0.0"
+	configs := []repoConfig{
+		{URL: "b", Source: &source, Tag: &tag, NextVersion: &bump, Version: &version, BranchTemplate: &branchTemplate, PreRelease: &preRelease},
+	}
+	opts := releaseOptions{sourceBranch: "main", targetBranch: "release", nextVersion: repo.PATCH}
+	noFlags := func(string) bool { return false }
+
+	targets := releaseTargets([]string{"a", "b"}, opts, configs, noFlags)
+
+	assert.Equal(t, []releaseTarget{
+		{repoURL: "a", opts: opts},
+		{repoURL: "b", opts: releaseOptions{
+			sourceBranch:   "develop",
+			targetBranch:   "release",
+			createTag:      true,
+			nextVersion:    repo.MAJOR,
+			version:        "3.0.0",
+			naming:         remote.Naming{BranchTemplate: "{{.Target}}-{{.Number}}"},
+			preReleaseHook: "make verify",
+		}},
+	}, targets)
+
+	sourceFlagSet := func(name string) bool { return name == "source" }
+	targets = releaseTargets([]string{"b"}, opts, configs, sourceFlagSet)
+	assert.Equal(t, "main", targets[0].opts.sourceBranch)
+	assert.Equal(t, true, targets[0].opts.createTag)
+
+	versionFlagSet := func(name string) bool { return name == "version" }
+	targets = releaseTargets([]string{"b"}, opts, configs, versionFlagSet)
+	assert.Equal(t, "", targets[0].opts.version)
+}
@@ -17,114 +17,592 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
 
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 
 	"github.com/spf13/viper"
 
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
 	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-var pat string
-var nextVersion int
+// RepoFieldName is the log field holding the repo url a log line belongs to
+const RepoFieldName = "repo"
+
+// releaseOptions holds all settings needed to create a new release for a single repo
+type releaseOptions struct {
+	sourceBranch string
+	targetBranch string
+	createBranch bool
+	createTag    bool
+	nextVersion  int
+	// version is the explicit version of the new release, it replaces nextVersion
+	version string
+	// line restricts the release to hotfixes of a release line like v1.3
+	line string
+	// scheme is semver or calver, calverFormat the format of calendar versions
+	scheme       string
+	calverFormat string
+	// now is the clock of calendar versions, nil uses the current time
+	now   func() time.Time
+	force bool
+	// dryRun only plans the release without pushing anything
+	dryRun bool
+	// timeout is the maximum duration of a release for a single repo, 0 means no timeout
+	timeout time.Duration
+	retry   remote.RetryPolicy
+	naming  remote.Naming
+	// credentials, username and pat are used for https urls and http urls with a pat, sshKey for all other urls
+	username       string
+	pat            string
+	sshKey         string
+	sshKeyPassword string
+	// shell commands run before and after the release of a repo, see hooks.go
+	preReleaseHook  string
+	postReleaseHook string
+	// freezes are the release freeze windows of the policy applying to the repo
+	freezes []freezeWindow
+	// freezeOverride is the reason for releasing during a freeze, empty refuses releases during a freeze
+	freezeOverride string
+	// allowMajor allows increasing the major version of a repo with a previous release
+	allowMajor bool
+	// maxJump is the maximum increase of a version number compared to the latest release, 0 disables the check
+	maxJump int
+	// snapshot computes a snapshot version instead of a release, it is tagged below snapshotTagPrefix if set
+	// and snapshot tags older than snapshotRetention are deleted
+	snapshot          bool
+	snapshotTagPrefix string
+	snapshotRetention time.Duration
+}
+
+// releaseTarget is a repo together with the options used to release it
+type releaseTarget struct {
+	repoURL string
+	opts    releaseOptions
+}
+
+// releaseResult is the outcome of an operation for a single repo
+type releaseResult struct {
+	repoURL string
+	// outcome is nil for skipped repos
+	outcome outcome
+	err     error
+}
+
+// operation runs a command like create for a single repo, opts.dryRun only plans the changes
+type operation func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error)
+
+// outcome is the result of an operation for a single repo, e.g. a releaseOutcome
+type outcome interface {
+	// describe summarizes the outcome in a few words
+	describe() string
+	// pending reports whether a planned operation would change the repo
+	pending() bool
+	// notification returns the outcome for the notifiers, with the status of a successful operation
+	notification() notify.Release
+}
+
+// releaseOutcome is the outcome of creating, finishing or promoting a release
+type releaseOutcome releaser.Result
+
+func (r releaseOutcome) describe() string {
+	return describeRelease(releaser.Result(r))
+}
+
+func (r releaseOutcome) pending() bool {
+	return !r.UpToDate
+}
+
+func (r releaseOutcome) notification() notify.Release {
+	n := notify.Release{Result: releaser.Result(r), Status: notify.StatusPlanned}
+	switch {
+	case r.Created:
+		n.Status = notify.StatusCreated
+	case r.UpToDate:
+		n.Status = notify.StatusUpToDate
+	}
+	return n
+}
+
+// createRelease is the operation of create, serve and schedule
+func createRelease(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error) {
+	res, err := createNewReleaseVersion(ctx, repoURL, opts, logger)
+	return releaseOutcome(res), err
+}
+
+// errSkipped is reported for repos which have not been started, because the run was interrupted
+var errSkipped = errors.New("skipped")
 
 // createCmd represents the branch command
 var createCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Creates a tag or version",
 	Long:  `Creates a tag or version`,
-	Run: func(cmd *cobra.Command, args []string) {
-		pat = viper.GetString("pat")
-		force := viper.GetBool("force")
-		nv := viper.GetString("nextversion")
-
-		nextVersion = setNextVersion(nv)
-
-		if len(repos) == 0 && fileName == "" {
-			log.Err(nil).Msg("Either -f (file) or -r (repos) must be set")
-			os.Exit(1)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(repos) == 0 && fileName == "" && viper.GetString("org") == "" && viper.GetString("gitlab-group") == "" {
+			return errors.New("either -f (file), -r (repos), --org or --gitlab-group must be set")
 		}
 
-		for _, r := range repos {
-			branchName, err := createNewReleaseVersion(r, force)
-			if err != nil {
-				log.Err(err).Msgf("For %s", r)
+		tmpl, err := notify.ParseTemplate(viper.GetString("notify-template"))
+		if err != nil {
+			return fmt.Errorf("invalid --notify-template: %w", err)
+		}
+		opts, err := newReleaseOptions()
+		if err != nil {
+			return err
+		}
+		// the version is read from the flag only, a VERSION environment variable is common in CI and must not apply to all repos
+		if v, _ := cmd.Flags().GetString("version"); v != "" {
+			scheme, _ := opts.versionScheme()
+			if opts.version, err = scheme.Parse(v); err != nil {
+				return err
 			}
-			if branchName != "" {
-				log.Info().Msgf("Successfully completed %s", r)
+		}
+		if viper.GetBool("snapshot") {
+			if opts.version != "" {
+				return errors.New("--snapshot can't be combined with --version")
+			}
+			if scheme, _ := opts.versionScheme(); scheme != (version.SemVer{}) {
+				return errors.New("--snapshot requires --scheme semver")
+			}
+			opts.snapshot = true
+			opts.snapshotTagPrefix = viper.GetString("snapshot-tag-prefix")
+			opts.snapshotRetention = viper.GetDuration("snapshot-retention")
+		}
+		// only create can override a freeze, serve and schedule always respect it
+		if viper.GetBool("override-freeze") {
+			if opts.freezeOverride = strings.TrimSpace(viper.GetString("reason")); opts.freezeOverride == "" {
+				return errors.New("--override-freeze requires a --reason")
 			}
-
 		}
+
+		return runOperation(cmd, opts, tmpl, createRelease)
 	},
 }
 
+// runOperation runs op for all repos of the flags, it is shared by create and the commands run by runFlow.
+// Unless --yes is set, more than --confirm-threshold changed repos have to be confirmed and --total-timeout aborts the whole run.
+func runOperation(cmd *cobra.Command, opts releaseOptions, tmpl *template.Template, op operation) error {
+	ctx := context.Background()
+	if totalTimeout := viper.GetDuration("total-timeout"); totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, totalTimeout)
+		defer cancel()
+	}
+
+	discovered, err := discoverRepos(ctx)
+	if err != nil {
+		return err
+	}
+
+	interrupt, stop := interruptChannel()
+	defer stop()
+
+	targets := releaseTargets(appendUnique(repos, discovered...), opts, repoConfigs, cmd.Flags().Changed)
+	if !opts.dryRun && !viper.GetBool("yes") {
+		if err := confirmRelease(ctx, interrupt, targets, viper.GetInt("concurrency"), viper.GetInt("confirm-threshold"), op); err != nil {
+			return err
+		}
+	}
+	results := releaseRepos(ctx, interrupt, targets, viper.GetInt("concurrency"), op)
+	logSummary(results)
+	if !opts.dryRun {
+		sendNotifications(results, tmpl, notifiers())
+	}
+	return nil
+}
+
 func init() {
 	flags := createCmd.Flags()
-	flags.StringP("pat", "p", "", `Use a Git Personal Access Token instead of the default private certificate! You could also set a environment variable. "export PAT=123456789" `)
-	_ = viper.BindPFlag("pat", flags.Lookup("pat"))
-	flags.Bool("force", false, `Creates a new release version, regardless of whether the last release is equal to the source branch or not`)
-	_ = viper.BindPFlag("force", flags.Lookup("force"))
+	addReleaseFlags(flags)
+	addNotifyFlags(flags)
+	addFreezeFlags(flags)
+	flags.Int("concurrency", 1, "How many repos should be released in parallel")
+	addRunFlags(flags)
+	flags.String("version", "", "Explicit SemVer version of the new releases like v3.0.0 instead of incrementing --nextversion, it must be greater than the latest release")
+	flags.Bool("snapshot", false, "Compute a snapshot version like v1.4.0-dev.20261017+g1a2b3c4 of the source branch from the next version, its commit date and hash, without creating a release")
+	flags.String("snapshot-tag-prefix", "", "Push the snapshot version as tag below this prefix, e.g. nightly/, empty only shows the snapshot version")
+	flags.Duration("snapshot-retention", 0, "Delete snapshot tags below --snapshot-tag-prefix with a commit date older than this duration, e.g. 720h, 0 keeps all")
+	_ = viper.BindPFlags(flags)
 	rootCmd.AddCommand(createCmd)
 }
 
-func createNewReleaseVersion(repoURL string, force bool) (string, error) {
-	var r *repo.Repo
-	if strings.Contains(repoURL, "https://") {
-		log.Info().Msgf(`Using PAT "-p" instead of ssh private certificate for repo %s`, repoURL)
-		r = repo.New(repoURL, &http.BasicAuth{
-			Username: "123", // Using a PAT this can be anything except an empty string
-			Password: pat,
-		})
-	} else {
-		r = repo.New(repoURL, nil)
+// addRunFlags defines the flags read by runOperation
+func addRunFlags(flags *pflag.FlagSet) {
+	flags.Duration("total-timeout", 0, "Maximum duration of the whole run, 0 disables the timeout")
+	flags.BoolP("yes", "y", false, "Release without asking for a confirmation")
+	flags.Int("confirm-threshold", 10, "Ask for a confirmation if more repos would be released, 0 never asks")
+}
+
+// addReleaseFlags defines the flags read by newReleaseOptions, they are shared by all commands creating releases.
+// The flags are bound to viper by the command using them.
+func addReleaseFlags(flags *pflag.FlagSet) {
+	addRepoFlags(flags)
+	flags.Bool("force", false, `Creates a new release version, regardless of whether the last release is equal to the source branch or not`)
+	flags.Bool("dry-run", false, "Only show which versions would be created, without pushing anything")
+	flags.String("branch-granularity", "patch", "Create a release branch per patch (release/v1.4.2), minor (release/v1.4) or major (release/v1) version, releases within an existing line branch are tags on its head")
+	flags.String("pre-release", "", "Shell command run before a new release of a repo is pushed, a non-zero exit aborts the release of the repo")
+	flags.String("post-release", "", "Shell command run after a new release of a repo has been pushed")
+	flags.Bool("allow-major", false, "Allow new major versions of repos which have already been released")
+	flags.Int("max-jump", 1, "Maximum increase of the major, minor or patch number compared to the latest release, 0 disables the check")
+	flags.String("line", "", "Release a hotfix of a release line like v1.3, only the patch number of its latest release is incremented. A --source with a version like release/v1.3.2 selects its line")
+}
+
+// addRepoFlags defines the flags of newReleaseOptions for the access, the release names and the versioning scheme of the repos.
+// Commands which don't create releases like yank and prune only define these flags and --dry-run.
+func addRepoFlags(flags *pflag.FlagSet) {
+	flags.StringP("pat", "p", "", `Use a Git Personal Access Token instead of the default private certificate! You could also set a environment variable. "export PAT=123456789" `)
+	flags.String("branch-template", remote.DefaultBranchTemplate, "Go template for the name of new release branches, available fields: .Target, .Version, .Number")
+	flags.String("tag-template", remote.DefaultTagTemplate, "Go template for the name of new release tags, available fields: .Target, .Version, .Number")
+	flags.Duration("timeout", 5*time.Minute, "Maximum duration of the release of a single repo, 0 disables the timeout")
+	flags.Int("retry-attempts", remote.DefaultRetryPolicy.Attempts, "How often a remote operation is tried in case of network or server errors, 1 disables retries")
+	flags.Duration("retry-backoff", remote.DefaultRetryPolicy.InitialBackoff, "Delay before the first retry, doubled for every further retry")
+	flags.Duration("retry-max-backoff", remote.DefaultRetryPolicy.MaxBackoff, "Maximum delay between two retries")
+	flags.Float64("retry-jitter", remote.DefaultRetryPolicy.Jitter, "Fraction (0 - 1) of the retry delay which is randomized")
+	flags.String("scheme", "semver", "Versioning scheme of the releases, semver or calver")
+	flags.String("calver-format", version.DefaultCalVerFormat, "Format of calendar versions of --scheme calver, e.g. YYYY.0M.MICRO or YY.MINOR.MICRO")
+}
+
+func newReleaseOptions() (releaseOptions, error) {
+	nextVersion, err := setNextVersion(viper.GetString("nextversion"))
+	if err != nil {
+		return releaseOptions{}, err
+	}
+	granularity, err := remote.ParseGranularity(viper.GetString("branch-granularity"))
+	if err != nil {
+		return releaseOptions{}, err
+	}
+	opts := releaseOptions{
+		sourceBranch: viper.GetString("source"),
+		targetBranch: viper.GetString("target"),
+		createBranch: viper.GetBool("branch"),
+		createTag:    viper.GetBool("tag"),
+		nextVersion:  nextVersion,
+		force:        viper.GetBool("force"),
+		dryRun:       viper.GetBool("dry-run"),
+		timeout:      viper.GetDuration("timeout"),
+		naming: remote.Naming{
+			BranchTemplate: viper.GetString("branch-template"),
+			TagTemplate:    viper.GetString("tag-template"),
+			Granularity:    granularity,
+		},
+		username:        viper.GetString("username"),
+		pat:             viper.GetString("pat"),
+		sshKey:          viper.GetString("ssh-key"),
+		sshKeyPassword:  viper.GetString("ssh-key-password"),
+		preReleaseHook:  viper.GetString("pre-release"),
+		postReleaseHook: viper.GetString("post-release"),
+		retry: remote.RetryPolicy{
+			Attempts:       viper.GetInt("retry-attempts"),
+			InitialBackoff: viper.GetDuration("retry-backoff"),
+			MaxBackoff:     viper.GetDuration("retry-max-backoff"),
+			Jitter:         viper.GetFloat64("retry-jitter"),
+		},
+		freezes:      freezeWindows,
+		allowMajor:   viper.GetBool("allow-major"),
+		maxJump:      viper.GetInt("max-jump"),
+		line:         viper.GetString("line"),
+		scheme:       viper.GetString("scheme"),
+		calverFormat: viper.GetString("calver-format"),
+	}
+	if err := opts.validateScheme(); err != nil {
+		return releaseOptions{}, err
+	}
+	if err := opts.naming.Validate(); err != nil {
+		return releaseOptions{}, err
+	}
+	if opts.line != "" {
+		if _, err := version.ParseLine(opts.line); err != nil {
+			return releaseOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+// interruptChannel returns a channel which is closed on the first SIGINT or SIGTERM,
+// afterwards the default signal handling is restored, so a second signal terminates the process immediately.
+// The returned func stops listening for signals.
+func interruptChannel() (<-chan struct{}, func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	interrupt := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			signal.Stop(sigs)
+			log.Warn().Msg("Interrupted, waiting for running releases to finish, press Ctrl-C again to abort immediately")
+			close(interrupt)
+		case <-done:
+		}
+	}()
+	return interrupt, func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// releaseRepos runs op, e.g. createRelease, for every repo using up to concurrency workers,
+// the results are in the same order as targets.
+// Once interrupt is closed no further repos are started, but running releases are completed.
+// Cancelling ctx aborts running releases as well.
+func releaseRepos(ctx context.Context, interrupt <-chan struct{}, targets []releaseTarget, concurrency int, op operation) []releaseResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]releaseResult, len(targets))
+	for i, t := range targets {
+		results[i] = releaseResult{repoURL: t.repoURL, err: errSkipped}
+	}
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				t := targets[i]
+				logger := log.With().Str(RepoFieldName, t.repoURL).Logger()
+				repoCtx, cancel := repoContext(ctx, t.opts)
+				res, err := op(repoCtx, t.repoURL, t.opts, &logger)
+				cancel()
+				if err != nil {
+					logger.Err(err).Msg("Release failed")
+				} else {
+					logger.Info().Msgf("Successfully completed, %s", res.describe())
+				}
+				results[i] = releaseResult{repoURL: t.repoURL, outcome: res, err: err}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range targets {
+		select {
+		case jobs <- i:
+		case <-interrupt:
+			break dispatch
+		case <-ctx.Done():
+			log.Err(ctx.Err()).Msg("Aborting run")
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// releaseRepo applies the per repo timeout to createNewReleaseVersion
+func releaseRepo(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (releaser.Result, error) {
+	ctx, cancel := repoContext(ctx, opts)
+	defer cancel()
+	return createNewReleaseVersion(ctx, repoURL, opts, logger)
+}
+
+// repoContext applies the per repo timeout of opts to ctx
+func repoContext(ctx context.Context, opts releaseOptions) (context.Context, context.CancelFunc) {
+	if opts.timeout > 0 {
+		return context.WithTimeout(ctx, opts.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func logSummary(results []releaseResult) {
+	if len(results) < 2 {
+		return
+	}
+	var failed, skipped int
+	log.Info().Msg("Summary:")
+	for _, res := range results {
+		if errors.Is(res.err, errSkipped) {
+			skipped++
+			log.Warn().Msgf("  SKIPPED %s", res.repoURL)
+		} else if res.err != nil {
+			failed++
+			log.Error().Msgf("  FAILED  %s: %v", res.repoURL, res.err)
+		} else {
+			log.Info().Msgf("  OK      %s: %s", res.repoURL, res.outcome.describe())
+		}
+	}
+	log.Info().Msgf("%d of %d repos completed successfully, %d failed, %d skipped", len(results)-failed-skipped, len(results), failed, skipped)
+}
+
+// describeRelease summarizes a release in a few words
+func describeRelease(r releaser.Result) string {
+	if r.Snapshot {
+		return describeSnapshot(r)
+	}
+	switch {
+	case r.UpToDate:
+		return fmt.Sprintf("nothing to do, %s is already released as %s", r.SourceBranch, r.PreviousVersion)
+	case r.Created:
+		return fmt.Sprintf("created %s%s%s", r.NextVersion, withTagMessage(r), withMerges(r))
+	default:
+		return fmt.Sprintf("would create %s%s%s", r.NextVersion, withTagMessage(r), withMerges(r))
 	}
+}
 
-	if r == nil {
-		return "", errors.New("could not get repo")
+// describeSnapshot summarizes a snapshot in a few words
+func describeSnapshot(r releaser.Result) string {
+	var s string
+	switch {
+	case r.Tag == "":
+		s = fmt.Sprintf("snapshot version %s", r.NextVersion)
+	case r.UpToDate:
+		s = fmt.Sprintf("snapshot tag %s already exists", r.Tag)
+	case r.Created:
+		s = fmt.Sprintf("created snapshot tag %s", r.Tag)
+	default:
+		s = fmt.Sprintf("would create snapshot tag %s", r.Tag)
+	}
+	if len(r.Pruned) > 0 {
+		s += fmt.Sprintf(", outdated snapshot tags %s", strings.Join(r.Pruned, ", "))
 	}
+	return s
+}
 
-	if ref := r.GetSourceBranch(sourceBranch); ref == nil {
-		return "", errors.New("could not get source branch")
+// withMerges lists the branches a finished git-flow release is merged into
+func withMerges(r releaser.Result) string {
+	if len(r.Merged) == 0 {
+		return ""
 	}
-	if createBranch {
-		r.GetVersionBranches(targetBranch)
+	verb := "merging"
+	if r.Created {
+		verb = "merged"
 	}
+	return fmt.Sprintf(", %s %s into %s", verb, r.SourceBranch, strings.Join(r.Merged, " and "))
+}
 
-	if createTag {
-		r.GetVersionTags()
+func withTagMessage(r releaser.Result) string {
+	if r.TagMessage == "" {
+		return ""
 	}
+	return fmt.Sprintf(" (%s)", r.TagMessage)
+}
 
-	r.GetLatestVersionReference()
+func createNewReleaseVersion(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (releaser.Result, error) {
+	ro, err := opts.releaserOptions(repoURL, logger)
+	if err != nil {
+		return releaser.Result{RepoURL: repoURL}, err
+	}
+	if opts.dryRun {
+		res, err := releaser.Plan(ctx, repoURL, ro)
+		if err == nil {
+			logger.Info().Msgf("Dry run: %s", describeRelease(res))
+		}
+		return res, err
+	}
+	return releaser.Release(ctx, repoURL, ro)
+}
 
-	_, err := r.NextReleaseVersion(nextVersion)
+// releaserOptions converts opts into the options of the releaser for repoURL
+func (o releaseOptions) releaserOptions(repoURL string, logger *zerolog.Logger) (releaser.Options, error) {
+	auth, err := o.auth(repoURL, logger)
 	if err != nil {
-		return "", err
+		return releaser.Options{}, err
+	}
+	scheme, err := o.versionScheme()
+	if err != nil {
+		return releaser.Options{}, err
+	}
+	ro := releaser.Options{
+		SourceBranch:      o.sourceBranch,
+		TargetBranch:      o.targetBranch,
+		CreateBranch:      o.createBranch,
+		CreateTag:         o.createTag,
+		NextVersion:       o.nextVersion,
+		Version:           o.version,
+		Line:              o.line,
+		Scheme:            scheme,
+		Force:             o.force,
+		Snapshot:          o.snapshot,
+		SnapshotTagPrefix: o.snapshotTagPrefix,
+		SnapshotRetention: o.snapshotRetention,
+		Auth:              auth,
+		Retry:             o.retry,
+		Naming:            o.naming,
+		Logger:            logger,
+		Policy:            o.policy(logger),
+		PreRelease:        releaseHook(preReleaseHook, o.preReleaseHook, logger),
+		PostRelease:       releaseHook(postReleaseHook, o.postReleaseHook, logger),
 	}
-	if err := r.CreateNewRelease(createBranch, createTag, force); err != nil {
-		return "", err
+	if w, ok := activeFreeze(o.freezes, time.Now()); ok && o.freezeOverride != "" {
+		ro.TagMessage = freezeTagMessage(w, o.freezeOverride)
 	}
-	return repoURL, nil
+	return ro, nil
 }
 
-func setNextVersion(version string) int {
-	switch version {
-	case "PATCH":
-		log.Info().Msg("New PATCH version will be created")
-		return repo.PATCH
-	case "MINOR":
-		log.Info().Msg("New MINOR version will be created")
-		return repo.MINOR
-	case "MAJOR":
-		log.Info().Msg("New MAJOR version will be created")
-		return repo.MAJOR
+// auth returns the credentials for repoURL, nil means the default ssh agent is used
+func (o releaseOptions) auth(repoURL string, logger *zerolog.Logger) (transport.AuthMethod, error) {
+	// plain http is only used by self-hosted forges, credentials are sent only if a PAT is given
+	if strings.Contains(repoURL, "https://") || (strings.HasPrefix(repoURL, "http://") && o.pat != "") {
+		logger.Info().Msgf(`Using PAT "-p" instead of ssh private certificate for repo %s`, repoURL)
+		username := o.username
+		if username == "" {
+			username = "123" // Using a PAT this can be anything except an empty string
+		}
+		return &http.BasicAuth{Username: username, Password: o.pat}, nil
+	}
+	if o.sshKey != "" {
+		return ssh.NewPublicKeysFromFile("git", o.sshKey, o.sshKeyPassword)
+	}
+	return nil, nil
+}
+
+// versionScheme returns the versioning scheme of the release
+func (o releaseOptions) versionScheme() (version.Scheme, error) {
+	switch strings.ToLower(o.scheme) {
+	case "", "semver":
+		return version.SemVer{}, nil
+	case "calver":
+		return version.NewCalVer(o.calverFormat, o.now)
 	default:
-		log.Info().Msgf("New MINOR version will be created, as %s is unknown", version)
-		return repo.MINOR
+		return version.SemVer{}, fmt.Errorf("invalid scheme %q, possible values: semver, calver", o.scheme)
+	}
+}
+
+// validateScheme checks the scheme and that only semantic versions use line branches
+func (o releaseOptions) validateScheme() error {
+	scheme, err := o.versionScheme()
+	if err != nil {
+		return err
+	}
+	if _, semver := scheme.(version.SemVer); !semver && o.naming.Granularity != remote.PerPatch {
+		return errors.New("--branch-granularity requires --scheme semver")
+	}
+	return nil
+}
+
+// nextVersions maps the values of --nextversion to the number which is incremented
+var nextVersions = map[string]int{"PATCH": repo.PATCH, "MINOR": repo.MINOR, "MAJOR": repo.MAJOR}
+
+// setNextVersion parses a value of --nextversion, unknown values are rejected
+func setNextVersion(version string) (int, error) {
+	next, ok := nextVersions[strings.ToUpper(version)]
+	if !ok {
+		return 0, fmt.Errorf("invalid nextversion %q, possible values: PATCH, MINOR, MAJOR", version)
 	}
+	log.Info().Msgf("New %s version will be created", strings.ToUpper(version))
+	return next, nil
 }
@@ -1,71 +1,260 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/fhopfensperger/git-releaser/pkg/freeze"
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
 	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_createNewReleaseVersion(t *testing.T) {
+	server := gittest.NewServer(t)
+	server.NewRepo("released", gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"))
+	server.NewRepo("unreleased", gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
 
 	type args struct {
 		repoUrl string
-		force   bool
+		opts    releaseOptions
 	}
 	tests := []struct {
 		name    string
 		args    args
-		want    string
+		want    bool
 		wantErr bool
 	}{
-		{
-			name:    "auth required",
-			args:    args{"https://github.com/fhopfensperger/amqp-sb-client.git", false},
-			want:    "",
-			wantErr: true,
-		},
 		{
 			name:    "Test repo doesnt exists",
-			args:    args{"https://github.com/fhopfensperger/i-do-not-exist.git", false},
-			want:    "",
+			args:    args{server.URL("i-do-not-exist"), releaseOptions{sourceBranch: "main", createTag: true}},
+			want:    false,
 			wantErr: true,
 		},
 		{
 			name:    "Test master branch doesnt exists",
-			args:    args{"https://github.com/fhopfensperger/git-releaser.git", false},
-			want:    "",
+			args:    args{server.URL("unreleased"), releaseOptions{sourceBranch: "master", createTag: true}},
+			want:    false,
 			wantErr: true,
 		},
 		{
-			name:    "Test master branch doesnt exists",
-			args:    args{"https://github.com/fhopfensperger/git-releaser.git", true},
-			want:    "",
+			name:    "Test master branch doesnt exists, force",
+			args:    args{server.URL("unreleased"), releaseOptions{sourceBranch: "master", createTag: true, force: true}},
+			want:    false,
 			wantErr: true,
 		},
+		{
+			name:    "Test already released",
+			args:    args{server.URL("released"), releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH}},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "Test dry run",
+			args:    args{server.URL("unreleased"), releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH, dryRun: true}},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "Test create branch and tag",
+			args:    args{server.URL("unreleased"), releaseOptions{sourceBranch: "main", targetBranch: "release", createBranch: true, createTag: true, nextVersion: repo.MINOR}},
+			want:    true,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sourceBranch = "master"
-			got, err := createNewReleaseVersion(tt.args.repoUrl, tt.args.force)
+			got, err := createNewReleaseVersion(context.Background(), tt.args.repoUrl, tt.args.opts, &log.Logger)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("createNewReleaseVersion() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("createNewReleaseVersion() got = %v, want %v", got, tt.want)
+			if got.Created != tt.want {
+				t.Errorf("createNewReleaseVersion() got = %v, want %v", got.Created, tt.want)
 			}
 		})
 	}
 }
 
+func Test_createNewReleaseVersion_pushes_refs(t *testing.T) {
+	server := gittest.NewServer(t)
+	r := server.NewRepo("app", gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	opts := releaseOptions{sourceBranch: "main", targetBranch: "release", createBranch: true, createTag: true, nextVersion: repo.MINOR}
+
+	got, err := createNewReleaseVersion(context.Background(), r.HTTPURL(), opts, &log.Logger)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "release/v1.1.0", got.Branch)
+	assert.Equal(t, "v1.1.0", got.Tag)
+	assert.Equal(t, []string{"main", "release/v1.1.0"}, r.Branches())
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, r.Tags())
+	assert.Equal(t, r.Hash("main"), r.Hash("release/v1.1.0"))
+	assert.Equal(t, r.Hash("main"), r.Hash("v1.1.0"))
+
+	// the source branch is released now, a second run is a no-op unless forced
+	got, err = createNewReleaseVersion(context.Background(), r.HTTPURL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, got.UpToDate)
+	assert.Len(t, r.Tags(), 2)
+
+	opts.force = true
+	got, err = createNewReleaseVersion(context.Background(), r.HTTPURL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, r.Hash("main"), r.Hash("v1.2.0"))
+}
+
+func Test_createNewReleaseVersion_file_url(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH}, &log.Logger)
+
+	assert.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, r.Hash("main"), r.Hash("v0.0.1"))
+}
+
+func Test_createNewReleaseVersion_auth(t *testing.T) {
+	server := gittest.NewServer(t)
+	r := server.NewRepo("private", gittest.Commit("initial"), gittest.Branch("main"))
+	server.RequireBasicAuth("bot", "secret")
+
+	_, err := createNewReleaseVersion(context.Background(), r.HTTPURL(), releaseOptions{sourceBranch: "main", createTag: true}, &log.Logger)
+	var authErr *remote.AuthError
+	assert.ErrorAs(t, err, &authErr)
+
+	got, err := createNewReleaseVersion(context.Background(), r.HTTPURL(), releaseOptions{sourceBranch: "main", createTag: true, username: "bot", pat: "secret"}, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+}
+
+func Test_createNewReleaseVersion_line(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("support"), gittest.Tag("v1.3.0"),
+		gittest.Commit("feature"), gittest.Branch("main"), gittest.Tag("v1.4.0"),
+		gittest.Checkout("support"), gittest.Commit("fix"), gittest.Branch("support"),
+	)
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), releaseOptions{sourceBranch: "support", createTag: true, nextVersion: repo.PATCH, line: "v1.3"}, &log.Logger)
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3", got.Line)
+	assert.Equal(t, r.Hash("support"), r.Hash("v1.3.1"))
+}
+
+func TestExecute_create_line(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("support"), gittest.Tag("v1.3.0"),
+		gittest.Commit("feature"), gittest.Branch("main"), gittest.Tag("v1.4.0"),
+		gittest.Checkout("support"), gittest.Commit("fix"), gittest.Branch("support"),
+	)
+	defer func() {
+		// the flags keep their values between executions
+		for _, f := range []*pflag.Flag{createCmd.Flags().Lookup("line"), rootCmd.PersistentFlags().Lookup("source"), rootCmd.PersistentFlags().Lookup("tag")} {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+		reposFlag := rootCmd.PersistentFlags().Lookup("repos")
+		_ = reposFlag.Value.(pflag.SliceValue).Replace(nil)
+		reposFlag.Changed = false
+	}()
+	rootCmd.SetArgs([]string{"create", "-r", r.URL(), "-s", "support", "-t", "-n", "PATCH", "--line", "v1.3"})
+
+	Execute("0.0.0")
+
+	assert.Equal(t, []string{"v1.3.0", "v1.3.1", "v1.4.0"}, r.Tags())
+	assert.Equal(t, r.Hash("support"), r.Hash("v1.3.1"))
+}
+
+func Test_createNewReleaseVersion_explicit_version(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.2.0"), gittest.Commit("rewrite"), gittest.Branch("main"))
+	// explicit versions are limited by --allow-major and --max-jump like incremented ones
+	opts := releaseOptions{sourceBranch: "main", createTag: true, version: "v3.0.0", maxJump: 1}
+
+	_, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorIs(t, err, errMajorNotAllowed)
+	opts.allowMajor = true
+	_, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorIs(t, err, errVersionJump)
+	assert.Equal(t, []string{"v1.2.0"}, r.Tags())
+
+	opts.maxJump = 2
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, []string{"v1.2.0", "v3.0.0"}, r.Tags())
+
+	// repeating the run is fine, as nothing has changed
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, got.UpToDate)
+
+	r.Apply(gittest.Commit("fix"), gittest.Branch("main"))
+	_, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorIs(t, err, repo.ErrVersionNotGreater)
+
+	opts.version = "v1.5.0"
+	_, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorIs(t, err, repo.ErrVersionNotGreater)
+	assert.Equal(t, []string{"v1.2.0", "v3.0.0"}, r.Tags())
+}
+
+func Test_createNewReleaseVersion_calver(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.4.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	now := func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+	opts := releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH, scheme: "calver", calverFormat: "YYYY.0M.MICRO", now: now, maxJump: 1}
+	today := "2026.10"
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, today+".0", got.NextVersion)
+
+	r.Apply(gittest.Commit("fix"), gittest.Branch("main"))
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, today+".0", got.PreviousVersion)
+	assert.Equal(t, today+".1", got.NextVersion)
+	assert.Equal(t, []string{today + ".0", today + ".1", "v1.4.0"}, r.Tags())
+
+	opts.scheme = "romver"
+	_, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorContains(t, err, "romver")
+}
+
+func Test_createNewReleaseVersion_snapshot(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.3.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	// the freeze doesn't apply to snapshots
+	opts := releaseOptions{sourceBranch: "main", nextVersion: repo.MINOR, snapshot: true, snapshotTagPrefix: "nightly/", maxJump: 1,
+		freezes: []freezeWindow{{Window: freeze.Window{Name: "always"}}}}
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0-dev.20200101+g"+r.Head()[:7], got.NextVersion)
+	assert.Equal(t, "created snapshot tag nightly/"+got.NextVersion, releaseOutcome(got).describe())
+	assert.Equal(t, []string{"nightly/" + got.NextVersion, "v1.3.0"}, r.Tags())
+}
+
 func Test_setNextVersion(t *testing.T) {
 	type args struct {
 		version string
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name    string
+		args    args
+		want    int
+		wantErr bool
 	}{
 		{
 			name: "MAJOR",
@@ -83,16 +272,164 @@ func Test_setNextVersion(t *testing.T) {
 			want: repo.PATCH,
 		},
 		{
-			name: "DEFAULT",
-			args: args{version: "IdontKnow"},
-			want: repo.MINOR,
+			name: "lower case",
+			args: args{version: "patch"},
+			want: repo.PATCH,
+		},
+		{
+			name:    "unknown",
+			args:    args{version: "IdontKnow"},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			args:    args{version: ""},
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := setNextVersion(tt.args.version); got != tt.want {
+			got, err := setNextVersion(tt.args.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setNextVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
 				t.Errorf("setNextVersion() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_releaseRepos_keeps_input_order(t *testing.T) {
+	repoURLs := []string{
+		"file:///i-do-not-exist-1.git",
+		"file:///i-do-not-exist-2.git",
+		"file:///i-do-not-exist-3.git",
+		"file:///i-do-not-exist-4.git",
+		"file:///i-do-not-exist-5.git",
+	}
+	targets := releaseTargets(repoURLs, releaseOptions{sourceBranch: "main"}, nil, nil)
+	results := releaseRepos(context.Background(), nil, targets, 3, createRelease)
+
+	assert.Len(t, results, len(repoURLs))
+	for i, res := range results {
+		assert.Equal(t, repoURLs[i], res.repoURL)
+		assert.Error(t, res.err)
+	}
+}
+
+func Test_releaseRepos_no_repos(t *testing.T) {
+	results := releaseRepos(context.Background(), nil, nil, 0, createRelease)
+	assert.Empty(t, results)
+}
+
+func Test_releaseRepos_interrupted(t *testing.T) {
+	interrupt := make(chan struct{})
+	close(interrupt)
+	repoURLs := []string{"file:///i-do-not-exist-1.git", "file:///i-do-not-exist-2.git"}
+
+	targets := releaseTargets(repoURLs, releaseOptions{sourceBranch: "main"}, nil, nil)
+	results := releaseRepos(context.Background(), interrupt, targets, 1, createRelease)
+
+	assert.Len(t, results, len(repoURLs))
+	for i, res := range results {
+		assert.Equal(t, repoURLs[i], res.repoURL)
+		assert.Error(t, res.err)
+	}
+}
+
+func Test_releaseRepos_total_timeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	repoURLs := []string{"file:///i-do-not-exist-1.git", "file:///i-do-not-exist-2.git"}
+
+	targets := releaseTargets(repoURLs, releaseOptions{sourceBranch: "main", timeout: time.Second}, nil, nil)
+	results := releaseRepos(ctx, nil, targets, 1, createRelease)
+
+	for _, res := range results {
+		assert.Error(t, res.err)
+	}
+}
+
+func Test_createNewReleaseVersion_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := createNewReleaseVersion(ctx, "file:///i-do-not-exist.git", releaseOptions{sourceBranch: "main"}, &log.Logger)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_interruptChannel_stop(t *testing.T) {
+	interrupt, stop := interruptChannel()
+	stop()
+	select {
+	case <-interrupt:
+		t.Error("interrupt channel must not be closed without a signal")
+	default:
+	}
+}
+
+func Test_describeRelease(t *testing.T) {
+	assert.Equal(t, "nothing to do, main is already released as v1.0.0", releaseOutcome{SourceBranch: "main", PreviousVersion: "v1.0.0", NextVersion: "v1.0.1", UpToDate: true}.describe())
+	assert.Equal(t, "created v1.0.1", releaseOutcome{NextVersion: "v1.0.1", Created: true}.describe())
+	assert.Equal(t, "would create v1.0.1", releaseOutcome{NextVersion: "v1.0.1"}.describe())
+	assert.Equal(t, "created v1.1.0, merged release/v1.1.0 into main and develop",
+		describeRelease(releaser.Result{SourceBranch: "release/v1.1.0", NextVersion: "v1.1.0", Created: true, Merged: []string{"main", "develop"}}))
+	assert.Equal(t, "snapshot version v1.1.0-dev.20261017+g1a2b3c4", releaseOutcome{NextVersion: "v1.1.0-dev.20261017+g1a2b3c4", Snapshot: true}.describe())
+	assert.Equal(t, "would create snapshot tag nightly/v1.1.0-dev.20261017+g1a2b3c4, outdated snapshot tags nightly/v1.1.0-dev.20260901+g0a1b2c3",
+		describeRelease(releaser.Result{Tag: "nightly/v1.1.0-dev.20261017+g1a2b3c4", Snapshot: true, Pruned: []string{"nightly/v1.1.0-dev.20260901+g0a1b2c3"}}))
+}
+
+func Test_logSummary(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&out)
+	defer func() { log.Logger = logger }()
+
+	logSummary([]releaseResult{
+		{repoURL: "git@github.com:fhopfensperger/a.git", outcome: releaseOutcome{NextVersion: "v1.0.1", Created: true}},
+		{repoURL: "git@github.com:fhopfensperger/b.git", err: errors.New("could not get source branch")},
+		{repoURL: "git@github.com:fhopfensperger/c.git", err: errSkipped},
+	})
+
+	assert.Contains(t, out.String(), `"level":"info","message":"  OK      git@github.com:fhopfensperger/a.git: `)
+	assert.Contains(t, out.String(), `"level":"error","message":"  FAILED  git@github.com:fhopfensperger/b.git: could not get source branch"`)
+	assert.Contains(t, out.String(), `"level":"warn","message":"  SKIPPED git@github.com:fhopfensperger/c.git"`)
+	assert.Contains(t, out.String(), `"message":"1 of 3 repos completed successfully, 1 failed, 1 skipped"`)
+}
+
+func Test_logSummary_single_repo(t *testing.T) {
+	var out bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&out)
+	defer func() { log.Logger = logger }()
+
+	logSummary([]releaseResult{{repoURL: "git@github.com:fhopfensperger/a.git", err: errors.New("failed")}})
+
+	assert.Empty(t, out.String())
+}
+
+func Test_releaseOptions_auth(t *testing.T) {
+	auth, err := releaseOptions{pat: "secret"}.auth("https://github.com/fhopfensperger/my-repo.git", &log.Logger)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "123", Password: "secret"}, auth)
+
+	auth, err = releaseOptions{username: "bot", pat: "secret"}.auth("https://github.com/fhopfensperger/my-repo.git", &log.Logger)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "bot", Password: "secret"}, auth)
+
+	auth, err = releaseOptions{pat: "secret"}.auth("http://gitea.local/fhopfensperger/my-repo.git", &log.Logger)
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "123", Password: "secret"}, auth)
+
+	auth, err = releaseOptions{}.auth("http://gitea.local/fhopfensperger/my-repo.git", &log.Logger)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	auth, err = releaseOptions{}.auth("git@github.com:fhopfensperger/my-repo.git", &log.Logger)
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	_, err = releaseOptions{sshKey: "i-do-not-exist"}.auth("git@github.com:fhopfensperger/my-repo.git", &log.Logger)
+	assert.Error(t, err)
+}
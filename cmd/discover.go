@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/fhopfensperger/git-releaser/pkg/forge"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	pf := rootCmd.PersistentFlags()
+	pf.String("org", "", "Use all repos of the GitHub organization, set GITHUB_TOKEN for private repos")
+	_ = viper.BindPFlag("org", pf.Lookup("org"))
+	pf.String("gitlab-group", "", "Use all repos of the GitLab group including its subgroups, set GITLAB_TOKEN for private repos")
+	_ = viper.BindPFlag("gitlab-group", pf.Lookup("gitlab-group"))
+	pf.String("github-url", forge.DefaultGitHubURL, "GitHub API url, e.g. https://github.example.com/api/v3 for GitHub Enterprise")
+	_ = viper.BindPFlag("github-url", pf.Lookup("github-url"))
+	pf.String("gitlab-url", forge.DefaultGitLabURL, "GitLab API url")
+	_ = viper.BindPFlag("gitlab-url", pf.Lookup("gitlab-url"))
+	pf.StringSlice("topic", []string{}, "Only use discovered repos having at least one of the topics")
+	_ = viper.BindPFlag("topic", pf.Lookup("topic"))
+	pf.String("name-regex", "", "Only use discovered repos whose name matches the regex")
+	_ = viper.BindPFlag("name-regex", pf.Lookup("name-regex"))
+	pf.String("visibility", "", "Only use discovered repos with the visibility: public, private or internal")
+	_ = viper.BindPFlag("visibility", pf.Lookup("visibility"))
+	pf.Bool("include-archived", false, "Also use archived discovered repos")
+	_ = viper.BindPFlag("include-archived", pf.Lookup("include-archived"))
+	pf.Bool("use-https", false, "Use the https instead of the ssh url of discovered repos")
+	_ = viper.BindPFlag("use-https", pf.Lookup("use-https"))
+}
+
+// discoveryFilter builds the filter for discovered repos from the flags
+func discoveryFilter() (forge.Filter, error) {
+	filter := forge.Filter{
+		Topics:          viper.GetStringSlice("topic"),
+		Visibility:      viper.GetString("visibility"),
+		IncludeArchived: viper.GetBool("include-archived"),
+	}
+	if nameRegex := viper.GetString("name-regex"); nameRegex != "" {
+		re, err := regexp.Compile(nameRegex)
+		if err != nil {
+			return filter, fmt.Errorf("invalid name-regex: %w", err)
+		}
+		filter.Name = re
+	}
+	return filter, nil
+}
+
+// discoverRepos returns the urls of all repos of the GitHub organization and GitLab group set by --org and --gitlab-group
+func discoverRepos(ctx context.Context) ([]string, error) {
+	org := viper.GetString("org")
+	group := viper.GetString("gitlab-group")
+	if org == "" && group == "" {
+		return nil, nil
+	}
+	filter, err := discoveryFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []forge.Repository
+	if org != "" {
+		gh := forge.GitHub{BaseURL: viper.GetString("github-url"), Token: viper.GetString("github-token")}
+		r, err := gh.OrgRepositories(ctx, org)
+		if err != nil {
+			return nil, fmt.Errorf("could not list repos of GitHub organization %s: %w", org, err)
+		}
+		found = append(found, r...)
+	}
+	if group != "" {
+		gl := forge.GitLab{BaseURL: viper.GetString("gitlab-url"), Token: viper.GetString("gitlab-token")}
+		r, err := gl.GroupRepositories(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("could not list repos of GitLab group %s: %w", group, err)
+		}
+		found = append(found, r...)
+	}
+
+	useHTTPS := viper.GetBool("use-https")
+	var urls []string
+	for _, r := range filter.Apply(found) {
+		urls = append(urls, r.CloneURL(useHTTPS))
+	}
+	log.Info().Msgf("Discovered %d of %d repos", len(urls), len(found))
+	return urls, nil
+}
+
+// appendUnique appends all urls of add which are not part of urls yet
+func appendUnique(urls []string, add ...string) []string {
+	seen := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		seen[u] = true
+	}
+	for _, u := range add {
+		if !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
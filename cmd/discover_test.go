@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_discoverRepos(t *testing.T) {
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"name": "api", "ssh_url": "git@github.com:acme/api.git", "clone_url": "https://github.com/acme/api.git", "topics": []string{"release"}},
+			{"name": "docs", "ssh_url": "git@github.com:acme/docs.git", "clone_url": "https://github.com/acme/docs.git"},
+			{"name": "old", "ssh_url": "git@github.com:acme/old.git", "clone_url": "https://github.com/acme/old.git", "topics": []string{"release"}, "archived": true},
+		})
+	}))
+	defer github.Close()
+	gitlab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"name": "worker", "ssh_url_to_repo": "git@gitlab.com:platform/worker.git", "http_url_to_repo": "https://gitlab.com/platform/worker.git", "topics": []string{"release"}},
+		})
+	}))
+	defer gitlab.Close()
+
+	settings := map[string]interface{}{
+		"org":          "acme",
+		"gitlab-group": "platform",
+		"github-url":   github.URL,
+		"gitlab-url":   gitlab.URL,
+		"topic":        []string{"release"},
+	}
+	for k, v := range settings {
+		viper.Set(k, v)
+	}
+	defer func() {
+		for k := range settings {
+			viper.Set(k, nil)
+		}
+		viper.Set("use-https", nil)
+	}()
+
+	urls, err := discoverRepos(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git@github.com:acme/api.git", "git@gitlab.com:platform/worker.git"}, urls)
+
+	viper.Set("use-https", true)
+	urls, err = discoverRepos(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://github.com/acme/api.git", "https://gitlab.com/platform/worker.git"}, urls)
+}
+
+func Test_discoverRepos_disabled(t *testing.T) {
+	urls, err := discoverRepos(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, urls)
+}
+
+func Test_discoveryFilter_invalid_regex(t *testing.T) {
+	viper.Set("name-regex", "(")
+	defer viper.Set("name-regex", nil)
+
+	_, err := discoveryFilter()
+	assert.Error(t, err)
+}
+
+func Test_appendUnique(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, appendUnique([]string{"a", "b"}, "b", "c", "c"))
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// flowCmd represents the flow command
+var flowCmd = &cobra.Command{
+	Use:   "flow",
+	Short: "Starts and finishes git-flow releases",
+	Long: `Releases repos following git-flow: start creates the release branch of the next version like release/v1.2.0 from the develop branch,
+finish merges the latest release branch into the main branch, tags the merge commit and merges it back into the develop branch.`,
+}
+
+// flowStartCmd represents the flow start command
+var flowStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Creates the next release branch from the develop branch",
+	Long: `Creates the release branch of the next version like release/v1.2.0 from --develop, like create --branch --source develop does,
+but the latest release is determined from the release branches and tags. No tag is created, it is created by finish.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// the release flags of flow are only bound if it runs, otherwise they would replace the ones of create
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flow := releaser.FlowOptions{Develop: viper.GetString("develop")}
+		return runFlow(cmd, flowOperation(releaser.Start, flow, ""))
+	},
+}
+
+// flowFinishCmd represents the flow finish command
+var flowFinishCmd = &cobra.Command{
+	Use:   "finish",
+	Short: "Merges the latest release branch into the main and develop branches and tags it",
+	Long: `Merges the latest release branch below --target, or the one of --version, into --main, tags the merge commit with the version
+and merges it back into --develop. The merges are performed in memory, a merge conflict fails the repo and nothing is pushed.
+Both branches and the tag are pushed atomically, the release branch is kept. A release which is already tagged is skipped.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flow := releaser.FlowOptions{Main: viper.GetString("main"), Develop: viper.GetString("develop")}
+		// like for create, the version is read from the flag only
+		v, _ := cmd.Flags().GetString("version")
+		return runFlow(cmd, flowOperation(releaser.Finish, flow, v))
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{flowStartCmd, flowFinishCmd} {
+		flags := c.Flags()
+		addReleaseFlags(flags)
+		addNotifyFlags(flags)
+		addFlowFlags(flags)
+		flowCmd.AddCommand(c)
+	}
+	flowFinishCmd.Flags().String("main", "main", "Branch receiving the finished releases and their tags")
+	flowFinishCmd.Flags().String("version", "", "Finish the release branch of this version instead of the latest one, e.g. v1.2.0")
+	rootCmd.AddCommand(flowCmd)
+}
+
+// addFlowFlags defines the flags shared by the flow commands
+func addFlowFlags(flags *pflag.FlagSet) {
+	flags.String("develop", "develop", "Integration branch the release branches are started from and merged back into")
+	flags.Int("concurrency", 1, "How many repos should be released in parallel")
+	addRunFlags(flags)
+}
+
+// flowOperation returns the operation running the git-flow command run like releaser.Finish, version replaces the version of the repos if set
+func flowOperation(run func(context.Context, string, releaser.Options, releaser.FlowOptions) (releaser.Result, error), flow releaser.FlowOptions, version string) operation {
+	return func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error) {
+		if version != "" {
+			opts.version = version
+		}
+		ro, err := opts.releaserOptions(repoURL, logger)
+		if err != nil {
+			return releaseOutcome{RepoURL: repoURL}, err
+		}
+		flow.DryRun = opts.dryRun
+		res, err := run(ctx, repoURL, ro, flow)
+		return releaseOutcome(res), err
+	}
+}
+
+// runFlow validates the flags shared with create and runs op for all repos, e.g. a git-flow command or yank
+func runFlow(cmd *cobra.Command, op operation) error {
+	if len(repos) == 0 && fileName == "" && viper.GetString("org") == "" && viper.GetString("gitlab-group") == "" {
+		return errors.New("either -f (file), -r (repos), --org or --gitlab-group must be set")
+	}
+	tmpl, err := notify.ParseTemplate(viper.GetString("notify-template"))
+	if err != nil {
+		return fmt.Errorf("invalid --notify-template: %w", err)
+	}
+	opts, err := newReleaseOptions()
+	if err != nil {
+		return err
+	}
+	return runOperation(cmd, opts, tmpl, op)
+}
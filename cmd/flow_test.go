@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_flowOperation(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"),
+		gittest.CommitFile("feature", "FEATURE", "feature\n"), gittest.Branch("develop"),
+	)
+	ctx := context.Background()
+	// flow start, see flowStartCmd
+	start := flowOperation(releaser.Start, releaser.FlowOptions{Develop: "develop"}, "")
+	opts := releaseOptions{targetBranch: "release", nextVersion: repo.MINOR}
+	got, err := start(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.1.0", got.(releaseOutcome).Branch)
+	assert.Equal(t, r.Hash("develop"), r.Hash("release/v1.1.0"))
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+
+	finish := flowOperation(releaser.Finish, releaser.FlowOptions{Main: "main", Develop: "develop"}, "")
+	opts.dryRun = true
+	got, err = finish(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "would create v1.1.0, merging release/v1.1.0 into main and develop", got.describe())
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+
+	opts.dryRun = false
+	got, err = finish(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, got.(releaseOutcome).Created)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, r.Tags())
+	assert.Equal(t, r.Hash("main"), r.Hash("v1.1.0"))
+	assert.Equal(t, "feature\n", r.File("main", "FEATURE"))
+
+	// develop contains the merge commit now, so the next release can be started
+	opts.nextVersion = repo.PATCH
+	got, err = start(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.1.1", got.(releaseOutcome).Branch)
+}
+
+func Test_flowOperation_conflict(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("develop"),
+		gittest.Commit("release"), gittest.Branch("release/v1.0.0"),
+		gittest.Checkout("main"), gittest.Commit("hotfix"), gittest.Branch("main"),
+	)
+	finish := flowOperation(releaser.Finish, releaser.FlowOptions{Main: "main", Develop: "develop"}, "")
+	opts := releaseOptions{targetBranch: "release", dryRun: true}
+
+	_, err := finish(context.Background(), r.URL(), opts, &log.Logger)
+
+	var conflict *remote.MergeConflictError
+	assert.ErrorAs(t, err, &conflict)
+}
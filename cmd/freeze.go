@@ -0,0 +1,162 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/freeze"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+)
+
+// freezeWindows holds the freeze windows of the policy section of the config file
+var freezeWindows []freezeWindow
+
+// errFrozen is returned for repos which would be released during a release freeze
+var errFrozen = errors.New("release freeze")
+
+// freezeConfig is a single entry of the policy.freezes list of the config file
+type freezeConfig struct {
+	Name string `mapstructure:"name"`
+	// From and To are dates like 2026-12-24, both days are included
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+	// Weekdays like sat or Saturday
+	Weekdays []string `mapstructure:"weekdays"`
+	// Time is a time of day range like 15:00-24:00
+	Time string `mapstructure:"time"`
+	// Timezone all constraints are evaluated in, defaults to the local time zone
+	Timezone string `mapstructure:"timezone"`
+	// Groups the freeze applies to, all repos if empty
+	Groups []string `mapstructure:"groups"`
+}
+
+// freezeWindow is a freeze window together with the groups it applies to
+type freezeWindow struct {
+	freeze.Window
+	groups []string
+}
+
+// addFreezeFlags defines the flags allowing releases during a release freeze
+func addFreezeFlags(flags *pflag.FlagSet) {
+	flags.Bool("override-freeze", false, "Release even if a release freeze of the policy is active, requires --reason")
+	flags.String("reason", "", "Why a release freeze is overridden, added to the report and the message of the annotated release tag")
+}
+
+// parseFreezes reads the policy.freezes list of the config file
+func parseFreezes(raw interface{}) ([]freezeWindow, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("policy.freezes must be a list, got %T", raw)
+	}
+	var windows []freezeWindow
+	for i, e := range entries {
+		var fc freezeConfig
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{DecodeHook: dateToString, Result: &fc})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(e); err != nil {
+			return nil, fmt.Errorf("invalid policy.freezes entry %d: %w", i+1, err)
+		}
+		if fc.Name == "" {
+			return nil, fmt.Errorf("policy.freezes entry %d has no name", i+1)
+		}
+		w, err := fc.window()
+		if err != nil {
+			return nil, fmt.Errorf("freeze %s: %w", fc.Name, err)
+		}
+		windows = append(windows, freezeWindow{Window: w, groups: fc.Groups})
+	}
+	return windows, nil
+}
+
+// dateToString converts unquoted YAML dates, which are decoded as time.Time, back to strings
+func dateToString(from, to reflect.Type, data interface{}) (interface{}, error) {
+	if t, ok := data.(time.Time); ok && to.Kind() == reflect.String {
+		return t.Format("2006-01-02"), nil
+	}
+	return data, nil
+}
+
+func (fc freezeConfig) window() (freeze.Window, error) {
+	w := freeze.Window{Name: fc.Name, Location: time.Local}
+	var err error
+	if fc.Timezone != "" {
+		if w.Location, err = time.LoadLocation(fc.Timezone); err != nil {
+			return w, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	if fc.From != "" {
+		if w.From, err = freeze.ParseDate(fc.From, w.Location); err != nil {
+			return w, err
+		}
+	}
+	if fc.To != "" {
+		if w.To, err = freeze.ParseDate(fc.To, w.Location); err != nil {
+			return w, err
+		}
+	}
+	if !w.From.IsZero() && !w.To.IsZero() && w.To.Before(w.From) {
+		return w, fmt.Errorf("to %s is before from %s", fc.To, fc.From)
+	}
+	for _, d := range fc.Weekdays {
+		day, err := freeze.ParseWeekday(d)
+		if err != nil {
+			return w, err
+		}
+		w.Weekdays = append(w.Weekdays, day)
+	}
+	if fc.Time != "" {
+		if w.Start, w.End, err = freeze.ParseTimeRange(fc.Time); err != nil {
+			return w, err
+		}
+	}
+	return w, nil
+}
+
+// freezesFor returns the windows applying to a repo of the given groups
+func freezesFor(windows []freezeWindow, groups []string) []freezeWindow {
+	var result []freezeWindow
+	for _, w := range windows {
+		if len(w.groups) == 0 || (repoConfig{Groups: groups}).inGroup(w.groups) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// activeFreeze returns the first of windows which is active at t
+func activeFreeze(windows []freezeWindow, t time.Time) (freeze.Window, bool) {
+	plain := make([]freeze.Window, len(windows))
+	for i, w := range windows {
+		plain[i] = w.Window
+	}
+	return freeze.ActiveWindow(plain, t)
+}
+
+// freezeTagMessage is the message of the annotated tag of a release overriding the freeze w
+func freezeTagMessage(w freeze.Window, reason string) string {
+	return fmt.Sprintf("Release freeze %s overridden: %s", w.Name, reason)
+}
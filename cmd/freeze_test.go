@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/freeze"
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseFreezes(t *testing.T) {
+	config := writeConfig(t, t.TempDir(), `
+policy:
+  freezes:
+    - name: holidays
+      from: 2026-12-20
+      to: "2027-01-06"
+      timezone: Europe/Berlin
+      groups: [backend]
+    - name: friday-afternoon
+      weekdays: [fri]
+      time: "15:00-24:00"
+`)
+	v := viper.New()
+	_, err := readConfigFiles(v, []string{config}, true)
+	require.NoError(t, err)
+
+	windows, err := parseFreezes(v.Get("policy.freezes"))
+
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "holidays (2026-12-20 - 2027-01-06, Europe/Berlin)", windows[0].String())
+	assert.Equal(t, []string{"backend"}, windows[0].groups)
+	assert.Equal(t, []time.Weekday{time.Friday}, windows[1].Weekdays)
+	assert.Equal(t, 15*time.Hour, windows[1].Start)
+	assert.Equal(t, time.Local, windows[1].Location)
+}
+
+func Test_parseFreezes_invalid(t *testing.T) {
+	for name, raw := range map[string]interface{}{
+		"no list":          map[string]interface{}{"name": "holidays"},
+		"no name":          []interface{}{map[string]interface{}{"weekdays": []interface{}{"sat"}}},
+		"invalid date":     []interface{}{map[string]interface{}{"name": "a", "from": "24.12.2026"}},
+		"reversed range":   []interface{}{map[string]interface{}{"name": "a", "from": "2027-01-06", "to": "2026-12-20"}},
+		"invalid weekday":  []interface{}{map[string]interface{}{"name": "a", "weekdays": []interface{}{"someday"}}},
+		"invalid time":     []interface{}{map[string]interface{}{"name": "a", "time": "15:00"}},
+		"invalid timezone": []interface{}{map[string]interface{}{"name": "a", "timezone": "Mars/Olympus"}},
+	} {
+		_, err := parseFreezes(raw)
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_releaseTargets_freezes(t *testing.T) {
+	windows := []freezeWindow{
+		{Window: freeze.Window{Name: "all"}},
+		{Window: freeze.Window{Name: "backend"}, groups: []string{"backend"}},
+	}
+	configs := []repoConfig{{URL: "git@github.com:acme/api.git", Groups: []string{"backend"}}}
+
+	targets := releaseTargets([]string{"git@github.com:acme/api.git", "git@github.com:acme/web.git"}, releaseOptions{freezes: windows}, configs, func(string) bool { return false })
+
+	assert.Len(t, targets[0].opts.freezes, 2)
+	require.Len(t, targets[1].opts.freezes, 1)
+	assert.Equal(t, "all", targets[1].opts.freezes[0].Name)
+}
+
+func Test_createNewReleaseVersion_freeze(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("fix"), gittest.Branch("main"))
+	holidays := freezeWindow{Window: freeze.Window{Name: "holidays"}}
+	opts := releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH, freezes: []freezeWindow{holidays}}
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	assert.ErrorIs(t, err, errFrozen)
+	assert.Contains(t, err.Error(), "--override-freeze")
+	assert.Equal(t, "v1.0.1", got.NextVersion)
+	assert.False(t, got.Created)
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+
+	opts.freezeOverride = "security fix"
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, "Release freeze holidays overridden: security fix", got.TagMessage)
+	assert.Equal(t, "created v1.0.1 (Release freeze holidays overridden: security fix)", releaseOutcome(got).describe())
+	assert.NotEqual(t, r.Head(), r.Hash("v1.0.1"), "the tag is annotated")
+
+	// nothing to release, nothing to refuse
+	opts.freezeOverride = ""
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, got.UpToDate)
+}
+
+func Test_createNewReleaseVersion_freeze_dry_run(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+	opts := releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.MAJOR, dryRun: true, freezes: []freezeWindow{{Window: freeze.Window{Name: "weekend"}}}}
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	assert.ErrorIs(t, err, errFrozen)
+	assert.Equal(t, "v1.0.0", got.NextVersion)
+	assert.Empty(t, r.Tags())
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog"
+)
+
+const (
+	preReleaseHook  = "pre_release"
+	postReleaseHook = "post_release"
+	// hookEnvPrefix prefixes all environment variables passed to hooks
+	hookEnvPrefix = "GIT_RELEASER_"
+)
+
+// hookPayload is passed as JSON on stdin to every hook
+type hookPayload struct {
+	Hook string `json:"hook"`
+	releaser.Result
+	Refs []string `json:"refs"`
+}
+
+// releaseHook returns a releaser hook running command, nil if command is empty
+func releaseHook(name, command string, logger *zerolog.Logger) func(context.Context, releaser.Result) error {
+	if command == "" {
+		return nil
+	}
+	return func(ctx context.Context, res releaser.Result) error {
+		return runHook(ctx, name, command, res, logger)
+	}
+}
+
+// runHook runs command with the shell, the release is described by environment variables and JSON on stdin.
+// The output of the command is logged line by line.
+func runHook(ctx context.Context, name, command string, res releaser.Result, logger *zerolog.Logger) error {
+	payload, err := json.Marshal(hookPayload{Hook: name, Result: res, Refs: res.Refs()})
+	if err != nil {
+		return err
+	}
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), hookEnv(name, res)...)
+	cmd.Stdin = bytes.NewReader(payload)
+
+	logger.Info().Msgf("Running %s hook: %s", name, command)
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\r\n"), "\n") {
+		if line != "" {
+			logger.Info().Msgf("%s: %s", name, strings.TrimRight(line, "\r"))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook %q: %w", name, command, err)
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+func hookEnv(name string, res releaser.Result) []string {
+	vars := []struct{ key, value string }{
+		{"HOOK", name},
+		{"REPO_URL", res.RepoURL},
+		{"SOURCE_BRANCH", res.SourceBranch},
+		{"SOURCE_COMMIT", res.SourceHash},
+		{"PREVIOUS_VERSION", res.PreviousVersion},
+		{"NEXT_VERSION", res.NextVersion},
+		{"BRANCH", res.Branch},
+		{"TAG", res.Tag},
+		{"REFS", strings.Join(res.Refs(), " ")},
+	}
+	env := make([]string, 0, len(vars))
+	for _, v := range vars {
+		env = append(env, hookEnvPrefix+v.key+"="+v.value)
+	}
+	return env
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_createNewReleaseVersion_hooks(t *testing.T) {
+	dir := t.TempDir()
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	opts := releaseOptions{
+		sourceBranch:    "main",
+		targetBranch:    "release",
+		createBranch:    true,
+		createTag:       true,
+		nextVersion:     repo.PATCH,
+		preReleaseHook:  `cat > "` + filepath.Join(dir, "pre.json") + `"; env > "` + filepath.Join(dir, "pre.env") + `"`,
+		postReleaseHook: `git ls-remote --tags "$GIT_RELEASER_REPO_URL" > "` + filepath.Join(dir, "post.txt") + `"`,
+	}
+
+	res, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+
+	var payload hookPayload
+	data, err := os.ReadFile(filepath.Join(dir, "pre.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &payload))
+	assert.Equal(t, preReleaseHook, payload.Hook)
+	assert.Equal(t, r.URL(), payload.RepoURL)
+	assert.Equal(t, "v1.0.0", payload.PreviousVersion)
+	assert.Equal(t, "v1.0.1", payload.NextVersion)
+	assert.Equal(t, r.Hash("main"), payload.SourceHash)
+	assert.Equal(t, []string{"refs/heads/release/v1.0.1", "refs/tags/v1.0.1"}, payload.Refs)
+	assert.False(t, payload.Created)
+
+	env, err := os.ReadFile(filepath.Join(dir, "pre.env"))
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "GIT_RELEASER_HOOK=pre_release\n")
+	assert.Contains(t, string(env), "GIT_RELEASER_NEXT_VERSION=v1.0.1\n")
+	assert.Contains(t, string(env), "GIT_RELEASER_SOURCE_COMMIT="+r.Hash("main")+"\n")
+	assert.Contains(t, string(env), "GIT_RELEASER_REFS=refs/heads/release/v1.0.1 refs/tags/v1.0.1\n")
+
+	// the post-release hook runs after the push
+	post, err := os.ReadFile(filepath.Join(dir, "post.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(post), "refs/tags/v1.0.1")
+}
+
+func Test_createNewReleaseVersion_failing_pre_release_hook(t *testing.T) {
+	dir := t.TempDir()
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+	opts := releaseOptions{
+		sourceBranch:    "main",
+		createTag:       true,
+		preReleaseHook:  "echo artifact missing; exit 3",
+		postReleaseHook: `touch "` + filepath.Join(dir, "post") + `"`,
+	}
+
+	res, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	assert.ErrorIs(t, err, releaser.ErrPreReleaseFailed)
+	assert.False(t, res.Created)
+	assert.Empty(t, r.Tags())
+	assert.NoFileExists(t, filepath.Join(dir, "post"))
+}
+
+func Test_createNewReleaseVersion_failing_post_release_hook(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+	opts := releaseOptions{sourceBranch: "main", createTag: true, postReleaseHook: "exit 1"}
+
+	res, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	assert.ErrorIs(t, err, releaser.ErrPostReleaseFailed)
+	assert.True(t, res.Created)
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+}
+
+func Test_createNewReleaseVersion_hooks_skipped(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"))
+	opts := releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH, preReleaseHook: "exit 1", postReleaseHook: "exit 1"}
+
+	// already released
+	res, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, res.UpToDate)
+
+	// dry run
+	opts.force, opts.dryRun = true, true
+	_, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.NoError(t, err)
+}
+
+func Test_hookEnv(t *testing.T) {
+	env := hookEnv(postReleaseHook, releaser.Result{RepoURL: "git@github.com:fhopfensperger/a.git", NextVersion: "v1.1.0", Tag: "v1.1.0", Created: true})
+
+	assert.Contains(t, env, "GIT_RELEASER_HOOK=post_release")
+	assert.Contains(t, env, "GIT_RELEASER_REPO_URL=git@github.com:fhopfensperger/a.git")
+	assert.Contains(t, env, "GIT_RELEASER_BRANCH=")
+	assert.Contains(t, env, "GIT_RELEASER_REFS=refs/tags/v1.1.0")
+	for _, e := range env {
+		assert.True(t, strings.HasPrefix(e, hookEnvPrefix), e)
+	}
+}
+
+func Test_releaseHook_empty(t *testing.T) {
+	assert.Nil(t, releaseHook(preReleaseHook, "", &log.Logger))
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"text/template"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// notifyTimeout limits the duration of all notifications of a run
+const notifyTimeout = 30 * time.Second
+
+// addNotifyFlags defines the flags read by notifiers
+func addNotifyFlags(flags *pflag.FlagSet) {
+	flags.String("slack-webhook", "", "Slack incoming webhook url, the releases of a run are announced in a single message")
+	flags.String("teams-webhook", "", "Microsoft Teams incoming webhook url, the releases of a run are announced in a single message")
+	flags.String("notify-webhook", "", "Url receiving the message and all releases of a run as JSON")
+	flags.String("notify-secret", "", "Secret used to sign the requests of --notify-webhook with HMAC-SHA256")
+	flags.String("notify-template", "", "Go template of the notification message, executed with .Releases and .Count, defaults to the created and failed releases")
+}
+
+// notifiers returns all notifiers configured by flags
+func notifiers() []notify.Notifier {
+	var n []notify.Notifier
+	if u := viper.GetString("slack-webhook"); u != "" {
+		n = append(n, notify.Slack{WebhookURL: u})
+	}
+	if u := viper.GetString("teams-webhook"); u != "" {
+		n = append(n, notify.Teams{WebhookURL: u})
+	}
+	if u := viper.GetString("notify-webhook"); u != "" {
+		n = append(n, notify.Webhook{URL: u, Secret: viper.GetString("notify-secret")})
+	}
+	return n
+}
+
+// notificationSummary converts the results of a run
+func notificationSummary(results []releaseResult) notify.Summary {
+	summary := notify.Summary{Releases: make([]notify.Release, 0, len(results))}
+	for _, res := range results {
+		var r notify.Release
+		if res.outcome != nil {
+			r = res.outcome.notification()
+		}
+		r.RepoURL = res.repoURL
+		switch {
+		case errors.Is(res.err, errSkipped):
+			r.Status = notify.StatusSkipped
+		case res.err != nil:
+			r.Status = notify.StatusFailed
+			r.Error = res.err.Error()
+		}
+		summary.Releases = append(summary.Releases, r)
+	}
+	return summary
+}
+
+// sendNotifications announces the results, failures are only logged and never fail the run
+func sendNotifications(results []releaseResult, tmpl *template.Template, n []notify.Notifier) {
+	if len(n) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := notify.Send(ctx, tmpl, notificationSummary(results), n...); err != nil {
+		log.Warn().Err(err).Msg("Sending notifications failed")
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_notificationSummary(t *testing.T) {
+	summary := notificationSummary([]releaseResult{
+		{repoURL: "a", outcome: releaseOutcome{NextVersion: "v1.0.1", Created: true}},
+		{repoURL: "b", outcome: releaseOutcome{UpToDate: true}},
+		{repoURL: "c", outcome: releaseOutcome{NextVersion: "v0.2.0"}},
+		{repoURL: "d", err: errors.New("could not get source branch")},
+		{repoURL: "e", err: errSkipped},
+		{repoURL: "f", outcome: yankOutcome{Version: "v1.0.5", Yanked: true}},
+		{repoURL: "g", outcome: pruneOutcome{Pruned: true}},
+	})
+
+	var statuses []string
+	for _, r := range summary.Releases {
+		statuses = append(statuses, r.RepoURL+"="+r.Status)
+	}
+	assert.Equal(t, []string{"a=created", "b=up_to_date", "c=planned", "d=failed", "e=skipped", "f=yanked", "g=pruned"}, statuses)
+	assert.Equal(t, "could not get source branch", summary.Releases[3].Error)
+}
+
+func Test_notifiers(t *testing.T) {
+	assert.Empty(t, notifiers())
+
+	viper.Set("slack-webhook", "https://hooks.slack.com/services/T/B/X")
+	viper.Set("notify-webhook", "https://example.com/releases")
+	viper.Set("notify-secret", "s3cret")
+	defer func() {
+		for _, k := range []string{"slack-webhook", "notify-webhook", "notify-secret"} {
+			viper.Set(k, nil)
+		}
+	}()
+
+	assert.Equal(t, []notify.Notifier{
+		notify.Slack{WebhookURL: "https://hooks.slack.com/services/T/B/X"},
+		notify.Webhook{URL: "https://example.com/releases", Secret: "s3cret"},
+	}, notifiers())
+}
+
+func Test_sendNotifications(t *testing.T) {
+	var payloads []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var p map[string]interface{}
+		_ = json.Unmarshal(body, &p)
+		payloads = append(payloads, p)
+	}))
+	defer server.Close()
+
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+	targets := releaseTargets([]string{r.URL(), "file:///i-do-not-exist.git"}, releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.MINOR}, nil, nil)
+	results := releaseRepos(context.Background(), nil, targets, 1, createRelease)
+	tmpl, _ := notify.ParseTemplate("")
+
+	sendNotifications(results, tmpl, []notify.Notifier{notify.Webhook{URL: server.URL}, notify.Slack{WebhookURL: "http://127.0.0.1:0"}})
+
+	require.Len(t, payloads, 1)
+	assert.Contains(t, payloads[0]["text"], "git-releaser: 1 released, 1 failed")
+	assert.Contains(t, payloads[0]["text"], r.URL()+" v0.1.0")
+	assert.Len(t, payloads[0]["releases"], 2)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// errMajorNotAllowed is returned for new major versions without --allow-major
+	errMajorNotAllowed = errors.New("major release not allowed")
+	// errVersionJump is returned if a new version skips more versions than --max-jump allows
+	errVersionJump = errors.New("version jump too large")
+	// errNotConfirmed is returned if the release of many repos hasn't been confirmed
+	errNotConfirmed = errors.New("release not confirmed")
+)
+
+// policy returns the checks of a new release, they run after planning and before anything is pushed
+func (o releaseOptions) policy(logger *zerolog.Logger) func(context.Context, releaser.Result) error {
+	return func(_ context.Context, res releaser.Result) error {
+		// the freeze is checked right before the release, so a long running release job can't slip into a freeze
+		if w, ok := activeFreeze(o.freezes, time.Now()); ok {
+			if o.freezeOverride == "" {
+				return fmt.Errorf(`%w %s is active, use --override-freeze --reason "..." to release anyway`, errFrozen, w)
+			}
+			logger.Warn().Msgf("Release freeze %s overridden: %s", w, o.freezeOverride)
+		}
+		// jumps are only checked for semantic versions, calendar versions jump with the date.
+		// Explicit versions are checked as well, a typo like v30.0.0 must not be released to all repos.
+		scheme, _ := o.versionScheme()
+		if _, semver := scheme.(version.SemVer); !semver {
+			return nil
+		}
+		// line branches like release/v1.4 have no version in their name, so the version of the previous release is compared
+		previous := res.PreviousRelease
+		if n := len(res.SkippedVersions); n > 0 {
+			// skipping yanked versions isn't a jump
+			previous = res.SkippedVersions[n-1]
+		}
+		return checkVersionJump(previous, res.NextVersion, o.allowMajor, o.maxJump)
+	}
+}
+
+// checkVersionJump compares the first differing number of the previous and the next version.
+// A higher major number requires allowMajor and no number may increase by more than maxJump.
+// Repos without a previous version are not checked.
+func checkVersionJump(previous, next string, allowMajor bool, maxJump int) error {
+	prev, ok := version.FromRef(previous)
+	if !ok {
+		return nil
+	}
+	nxt, ok := version.FromRef(next)
+	if !ok {
+		return nil
+	}
+	prevNumbers := [3]uint64{prev.Major, prev.Minor, prev.Patch}
+	nextNumbers := [3]uint64{nxt.Major, nxt.Minor, nxt.Patch}
+	for i := range prevNumbers {
+		if nextNumbers[i] == prevNumbers[i] {
+			continue
+		}
+		if nextNumbers[i] < prevNumbers[i] {
+			return nil
+		}
+		if i == 0 && !allowMajor {
+			return fmt.Errorf("%w: %s -> %s, use --allow-major to release a new major version", errMajorNotAllowed, previous, next)
+		}
+		if increase := nextNumbers[i] - prevNumbers[i]; maxJump > 0 && increase > uint64(maxJump) {
+			return fmt.Errorf("%w: %s -> %s increases a version number by %d, --max-jump allows %d", errVersionJump, previous, next, increase, maxJump)
+		}
+		return nil
+	}
+	return nil
+}
+
+// confirmRelease asks for a confirmation if more than threshold of the targets would be released.
+// The targets are planned first, which is skipped if there aren't more than threshold targets at all.
+func confirmRelease(ctx context.Context, interrupt <-chan struct{}, targets []releaseTarget, concurrency, threshold int, op operation) error {
+	if threshold <= 0 || len(targets) <= threshold {
+		return nil
+	}
+	log.Info().Msgf("Planning the releases of %d repos, more than %d new releases have to be confirmed", len(targets), threshold)
+	planned := make([]releaseTarget, len(targets))
+	for i, t := range targets {
+		t.opts.dryRun = true
+		planned[i] = t
+	}
+	return confirm(releaseRepos(ctx, interrupt, planned, concurrency, op), threshold, os.Stdin, os.Stderr, isTerminal(os.Stdin))
+}
+
+// confirm asks on out whether the planned releases should be created, if there are more than threshold
+func confirm(planned []releaseResult, threshold int, in io.Reader, out io.Writer, interactive bool) error {
+	var releases []releaseResult
+	for _, p := range planned {
+		if p.err == nil && p.outcome.pending() {
+			releases = append(releases, p)
+		}
+	}
+	if len(releases) <= threshold {
+		return nil
+	}
+	if !interactive {
+		return fmt.Errorf("%w: %d repos would be released, which is more than --confirm-threshold %d, use --yes to release them", errNotConfirmed, len(releases), threshold)
+	}
+	fmt.Fprintf(out, "%d repos would be released:\n", len(releases))
+	for _, r := range releases {
+		fmt.Fprintf(out, "  %s: %s\n", r.repoURL, r.outcome.describe())
+	}
+	fmt.Fprint(out, "Release them? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errNotConfirmed
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
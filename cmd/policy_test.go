@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_checkVersionJump(t *testing.T) {
+	tests := []struct {
+		previous, next string
+		allowMajor     bool
+		maxJump        int
+		wantErr        error
+	}{
+		{"", "v1.0.0", false, 1, nil},
+		{"v1.2.3", "v1.2.4", false, 1, nil},
+		{"release/v1.2.3", "v1.3.0", false, 1, nil},
+		{"v1.2.3", "v2.0.0", false, 1, errMajorNotAllowed},
+		{"v1.2.3", "v2.0.0", true, 1, nil},
+		{"v1.2.3", "v3.0.0", true, 1, errVersionJump},
+		{"v1.2.3", "v1.5.0", false, 1, errVersionJump},
+		{"v1.2.3", "v1.5.0", false, 2, errVersionJump},
+		{"v1.2.3", "v1.5.0", false, 3, nil},
+		{"v1.2.3", "v1.2.9", false, 0, nil},
+		{"v10.2", "v10.3.0", false, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.previous+"->"+tt.next, func(t *testing.T) {
+			err := checkVersionJump(tt.previous, tt.next, tt.allowMajor, tt.maxJump)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_createNewReleaseVersion_major(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.2.0"), gittest.Commit("breaking"), gittest.Branch("main"))
+	opts := releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.MAJOR, maxJump: 1}
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+
+	assert.ErrorIs(t, err, errMajorNotAllowed)
+	assert.Equal(t, "v2.0.0", got.NextVersion)
+	assert.Equal(t, []string{"v1.2.0"}, r.Tags())
+
+	opts.allowMajor = true
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.True(t, got.Created)
+	assert.Equal(t, []string{"v1.2.0", "v2.0.0"}, r.Tags())
+}
+
+func Test_createNewReleaseVersion_majorLineBranch(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("release/v1.4"), gittest.Commit("breaking"), gittest.Branch("main"))
+	opts := releaseOptions{sourceBranch: "main", targetBranch: "release", createBranch: true, nextVersion: repo.MAJOR}
+	opts.naming.Granularity = remote.PerMinor
+
+	got, err := createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.ErrorIs(t, err, errMajorNotAllowed)
+	assert.Equal(t, "release/v1.4", got.PreviousVersion)
+	assert.Equal(t, "v2.0.0", got.NextVersion)
+
+	opts.nextVersion, opts.maxJump = repo.MINOR, 1
+	got, err = createNewReleaseVersion(context.Background(), r.URL(), opts, &log.Logger)
+	assert.NoError(t, err)
+	assert.Equal(t, "release/v1.5", got.Branch)
+	assert.Equal(t, []string{"main", "release/v1.4", "release/v1.5"}, r.Branches())
+}
+
+func Test_confirm(t *testing.T) {
+	planned := []releaseResult{
+		{repoURL: "git@github.com:acme/a.git", outcome: releaseOutcome{PreviousVersion: "v1.0.0", NextVersion: "v1.1.0"}},
+		{repoURL: "git@github.com:acme/b.git", outcome: releaseOutcome{NextVersion: "v0.1.0"}},
+		{repoURL: "git@github.com:acme/c.git", outcome: releaseOutcome{UpToDate: true}},
+		{repoURL: "git@github.com:acme/d.git", err: errors.New("could not get source branch")},
+	}
+
+	// up to date and failing repos don't count
+	assert.NoError(t, confirm(planned, 2, strings.NewReader(""), &bytes.Buffer{}, false))
+
+	err := confirm(planned, 1, strings.NewReader(""), &bytes.Buffer{}, false)
+	assert.ErrorIs(t, err, errNotConfirmed)
+	assert.Contains(t, err.Error(), "--yes")
+
+	var out bytes.Buffer
+	require.NoError(t, confirm(planned, 1, strings.NewReader("y\n"), &out, true))
+	assert.Equal(t, `2 repos would be released:
+  git@github.com:acme/a.git: would create v1.1.0
+  git@github.com:acme/b.git: would create v0.1.0
+Release them? [y/N] `, out.String())
+
+	for _, answer := range []string{"n\n", "\n", ""} {
+		assert.ErrorIs(t, confirm(planned, 1, strings.NewReader(answer), &bytes.Buffer{}, true), errNotConfirmed, answer)
+	}
+}
+
+func Test_confirmRelease_below_threshold(t *testing.T) {
+	// nothing is planned, the targets don't exist
+	targets := []releaseTarget{{repoURL: "file:///i-do-not-exist.git"}}
+
+	assert.NoError(t, confirmRelease(context.Background(), nil, targets, 1, 1, createRelease))
+	assert.NoError(t, confirmRelease(context.Background(), nil, append(targets, targets...), 1, 0, createRelease))
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote <candidate>",
+	Short: "Releases a release candidate like v2.0.0-rc.3 as final version v2.0.0",
+	Long: `Releases the commit of the release candidate tag like v2.0.0-rc.3 as final version v2.0.0: the final tag, and the release branch
+with --branch, point to the same commit as the candidate tag. The promotion is refused if the final version or a newer final version
+exists, a candidate which is already promoted is skipped. --carry-message annotates the final tag with the message of the candidate tag,
+--carry-notes adds the messages of all candidates of the version up to the promoted one.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		promote := releaser.PromoteOptions{Candidate: args[0], CarryMessage: viper.GetBool("carry-message"), CarryNotes: viper.GetBool("carry-notes")}
+		return runFlow(cmd, promoteOperation(promote))
+	},
+}
+
+func init() {
+	flags := promoteCmd.Flags()
+	addReleaseFlags(flags)
+	addNotifyFlags(flags)
+	flags.Bool("carry-message", false, "Annotate the final tag with the message of the candidate tag")
+	flags.Bool("carry-notes", false, "Add the messages of all candidates of the version to the message of the final tag")
+	flags.Int("concurrency", 1, "How many repos should be released in parallel")
+	addRunFlags(flags)
+	rootCmd.AddCommand(promoteCmd)
+}
+
+// promoteOperation returns the operation promoting the release candidate of promote
+func promoteOperation(promote releaser.PromoteOptions) operation {
+	return func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error) {
+		ro, err := opts.releaserOptions(repoURL, logger)
+		if err != nil {
+			return releaseOutcome{RepoURL: repoURL}, err
+		}
+		promote.DryRun = opts.dryRun
+		res, err := releaser.Promote(ctx, repoURL, ro, promote)
+		return releaseOutcome(res), err
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_promoteOperation(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"),
+		gittest.Commit("rewrite"), gittest.AnnotatedTag("v2.0.0-rc.1", "New API"),
+		gittest.Commit("next"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	// see promoteCmd
+	promote := promoteOperation(releaser.PromoteOptions{Candidate: "v2.0.0-rc.1", CarryMessage: true})
+	opts := releaseOptions{targetBranch: "release", dryRun: true, allowMajor: true}
+
+	got, err := promote(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "would create v2.0.0 (New API)", got.describe())
+	assert.Equal(t, []string{"v1.0.0", "v2.0.0-rc.1"}, r.Tags())
+
+	opts.dryRun = false
+	got, err = promote(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.True(t, got.(releaseOutcome).Created)
+	assert.Equal(t, []string{"v1.0.0", "v2.0.0", "v2.0.0-rc.1"}, r.Tags())
+
+	got, err = promote(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "nothing to do, v2.0.0-rc.1 is already released as v2.0.0", got.describe())
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Deletes outdated release branches",
+	Long: `Deletes the outdated release branches below --target with a single push. --keep-patches keeps the latest branches of every minor version,
+--keep-minors the branches of the latest minor versions, all other branches are outdated. --older-than deletes branches whose commit is older
+and whose version is tagged as well, combined with the keep rules it only deletes branches which aren't kept.
+The latest release is never deleted.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prune := releaser.PruneOptions{KeepPatches: viper.GetInt("keep-patches"), KeepMinors: viper.GetInt("keep-minors"), OlderThan: viper.GetDuration("older-than")}
+		if prune.KeepPatches <= 0 && prune.KeepMinors <= 0 && prune.OlderThan <= 0 {
+			return errors.New("either --keep-patches, --keep-minors or --older-than must be set")
+		}
+		return runFlow(cmd, pruneOperation(prune))
+	},
+}
+
+func init() {
+	flags := pruneCmd.Flags()
+	addRepoFlags(flags)
+	flags.Bool("dry-run", false, "Only show which release branches would be deleted, without pushing anything")
+	addNotifyFlags(flags)
+	flags.Int("keep-patches", 0, "Keep the latest release branches of every minor version, e.g. 3 keeps release/v1.4.3 to release/v1.4.5, 0 disables the rule")
+	flags.Int("keep-minors", 0, "Keep the release branches of the latest minor versions, 0 disables the rule")
+	flags.Duration("older-than", 0, "Delete release branches whose commit is older, e.g. 2160h, if their version is tagged as well and they aren't kept by --keep-patches or --keep-minors, 0 disables the rule")
+	flags.Int("concurrency", 1, "How many repos should be pruned in parallel")
+	addRunFlags(flags)
+	rootCmd.AddCommand(pruneCmd)
+}
+
+// pruneOperation returns the operation deleting the outdated release branches by the rules of prune
+func pruneOperation(prune releaser.PruneOptions) operation {
+	return func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error) {
+		ro, err := opts.releaserOptions(repoURL, logger)
+		if err != nil {
+			return pruneOutcome{RepoURL: repoURL}, err
+		}
+		prune.DryRun = opts.dryRun
+		res, err := releaser.Prune(ctx, repoURL, ro, prune)
+		return pruneOutcome(res), err
+	}
+}
+
+// pruneOutcome is the outcome of prune
+type pruneOutcome releaser.PruneResult
+
+func (p pruneOutcome) describe() string {
+	switch {
+	case p.UpToDate:
+		return "nothing to prune"
+	case p.Pruned:
+		return fmt.Sprintf("deleted %d release branches: %s", len(p.Deleted), strings.Join(p.Deleted, ", "))
+	default:
+		return fmt.Sprintf("would delete %d release branches: %s", len(p.Deleted), strings.Join(p.Deleted, ", "))
+	}
+}
+
+func (p pruneOutcome) pending() bool {
+	return !p.UpToDate
+}
+
+func (p pruneOutcome) notification() notify.Release {
+	res := releaser.PruneResult(p)
+	n := notify.Release{Prune: &res, Status: notify.StatusPlanned}
+	switch {
+	case p.Pruned:
+		n.Status = notify.StatusPruned
+	case p.UpToDate:
+		n.Status = notify.StatusUpToDate
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_pruneOperation(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("release/v1.0.0"),
+		gittest.Commit("fix"), gittest.Branch("release/v1.0.1"),
+		gittest.Commit("feature"), gittest.Branch("release/v1.1.0"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	// see pruneCmd
+	prune := pruneOperation(releaser.PruneOptions{KeepMinors: 1})
+	opts := releaseOptions{targetBranch: "release", dryRun: true}
+
+	got, err := prune(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "would delete 2 release branches: release/v1.0.0, release/v1.0.1", got.describe())
+	assert.Len(t, r.Branches(), 4)
+
+	opts.dryRun = false
+	got, err = prune(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted 2 release branches: release/v1.0.0, release/v1.0.1", got.describe())
+	assert.Equal(t, []string{"main", "release/v1.1.0"}, r.Branches())
+
+	got, err = prune(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "nothing to prune", got.describe())
+}
+
+func Test_pruneCmd_flags(t *testing.T) {
+	// prune doesn't create releases, so the flags of new releases aren't offered
+	for _, name := range []string{"pre-release", "post-release", "allow-major", "max-jump", "line", "force", "branch-granularity"} {
+		assert.Nil(t, pruneCmd.Flags().Lookup(name), name)
+	}
+	assert.Contains(t, pruneCmd.Flags().Lookup("dry-run").Usage, "deleted")
+}
@@ -0,0 +1,222 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+// stdinFileName reads the repos file from stdin
+const stdinFileName = "-"
+
+// trailingComment matches a # at the beginning of a line or preceded by whitespace, and everything after it
+var trailingComment = regexp.MustCompile(`(^|\s)#.*$`)
+
+// optionAliases maps short inline option names to the names used in the config file
+var optionAliases = map[string]string{
+	"bump": "nextversion",
+}
+
+// reposFileReader reads repos files, keeping track of the included files to detect include cycles
+type reposFileReader struct {
+	stdin     io.Reader
+	including map[string]bool
+}
+
+// readReposFile reads the repos from fileName, "-" reads from stdin.
+// Files ending with .yaml, .yml or .json are read as manifest containing a repos list like the config file,
+// all other files are read line by line, see parseReposList.
+func readReposFile(fileName string) ([]repoConfig, error) {
+	r := reposFileReader{stdin: os.Stdin, including: map[string]bool{}}
+	return r.read(fileName, "")
+}
+
+func (r *reposFileReader) read(fileName, group string) ([]repoConfig, error) {
+	if fileName == stdinFileName {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		return r.parseReposList(r.stdin, wd, group)
+	}
+
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+	if r.including[abs] {
+		return nil, fmt.Errorf("include cycle detected for file %s", fileName)
+	}
+	r.including[abs] = true
+	defer delete(r.including, abs)
+
+	content, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(abs)); ext {
+	case ".yaml", ".yml", ".json":
+		configs, err := parseManifest(content, strings.TrimPrefix(ext, "."))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		return withGroup(configs, group), nil
+	default:
+		configs, err := r.parseReposList(bytes.NewReader(content), filepath.Dir(abs), group)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		return configs, nil
+	}
+}
+
+// parseReposList parses one repo per line:
+//
+//	# comment, also allowed at the end of a line
+//	[backend]                                  all following repos belong to the group backend
+//	git@github.com:org/repo.git source=develop bump=MINOR tag=true
+//	!include other.txt                         relative to the directory of the current file
+//
+// Environment variables like $HOME or ${HOME} are expanded.
+func (r *reposFileReader) parseReposList(in io.Reader, dir, group string) ([]repoConfig, error) {
+	var configs []repoConfig
+	scanner := bufio.NewScanner(in)
+	scanner.Split(bufio.ScanLines)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(os.ExpandEnv(trailingComment.ReplaceAllString(scanner.Text(), "")))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			group = strings.TrimSpace(line[1 : len(line)-1])
+		case strings.HasPrefix(line, "!include"):
+			includeFile := strings.TrimSpace(strings.TrimPrefix(line, "!include"))
+			if includeFile == "" {
+				return nil, fmt.Errorf("line %d: !include without file name", lineNumber)
+			}
+			if !filepath.IsAbs(includeFile) {
+				includeFile = filepath.Join(dir, includeFile)
+			}
+			included, err := r.read(includeFile, group)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, included...)
+		default:
+			rc, err := parseRepoLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			configs = append(configs, withGroup([]repoConfig{rc}, group)...)
+		}
+	}
+	return configs, scanner.Err()
+}
+
+// parseRepoLine parses a repo url followed by optional key=value options
+func parseRepoLine(line string) (repoConfig, error) {
+	fields := strings.Fields(line)
+	options := map[string]interface{}{"url": fields[0]}
+	for _, f := range fields[1:] {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok || key == "" {
+			return repoConfig{}, fmt.Errorf("invalid option %q, expected key=value", f)
+		}
+		key = strings.ToLower(key)
+		if alias, ok := optionAliases[key]; ok {
+			key = alias
+		}
+		if key == "groups" {
+			options[key] = strings.Split(value, ",")
+			continue
+		}
+		options[key] = value
+	}
+
+	var rc repoConfig
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           &rc,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+	})
+	if err != nil {
+		return repoConfig{}, err
+	}
+	if err := decoder.Decode(options); err != nil {
+		return repoConfig{}, err
+	}
+	return rc, rc.validate()
+}
+
+// parseManifest reads the repos list of a YAML or JSON document, environment variables are expanded
+func parseManifest(content []byte, configType string) ([]repoConfig, error) {
+	v := viper.New()
+	v.SetConfigType(configType)
+	if err := v.ReadConfig(strings.NewReader(os.ExpandEnv(string(content)))); err != nil {
+		return nil, err
+	}
+	return parseRepoConfigs(v.Get("repos"))
+}
+
+// withGroup adds group to all configs without a group
+func withGroup(configs []repoConfig, group string) []repoConfig {
+	if group == "" {
+		return configs
+	}
+	for i := range configs {
+		if len(configs[i].Groups) == 0 {
+			configs[i].Groups = []string{group}
+		}
+	}
+	return configs
+}
+
+// filterGroups returns all configs belonging to at least one of groups, all configs if no group is given
+func filterGroups(configs []repoConfig, groups []string) []repoConfig {
+	if len(groups) == 0 {
+		return configs
+	}
+	var filtered []repoConfig
+	for _, rc := range configs {
+		if rc.inGroup(groups) {
+			filtered = append(filtered, rc)
+		}
+	}
+	return filtered
+}
+
+func (rc repoConfig) inGroup(groups []string) bool {
+	for _, want := range groups {
+		for _, g := range rc.Groups {
+			if g == want {
+				return true
+			}
+		}
+	}
+	return false
+}
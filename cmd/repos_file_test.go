@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	fileName := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(fileName, []byte(content), 0o600))
+	return fileName
+}
+
+func Test_readReposFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GIT_HOST", "github.com")
+	writeFile(t, dir, "frontend.txt", `
+git@github.com:fhopfensperger/web.git tag=true
+`)
+	fileName := writeFile(t, dir, "repos.txt", `
+# all repos
+git@github.com:fhopfensperger/a.git # trailing comment
+[backend]
+git@${GIT_HOST}:fhopfensperger/b.git source=develop bump=MINOR branch=false
+[frontend]
+!include frontend.txt
+`)
+
+	configs, err := readReposFile(fileName)
+
+	assert.NoError(t, err)
+	assert.Len(t, configs, 3)
+	assert.Equal(t, "git@github.com:fhopfensperger/a.git", configs[0].URL)
+	assert.Empty(t, configs[0].Groups)
+	assert.Equal(t, "git@github.com:fhopfensperger/b.git", configs[1].URL)
+	assert.Equal(t, []string{"backend"}, configs[1].Groups)
+	assert.Equal(t, "develop", *configs[1].Source)
+	assert.Equal(t, "MINOR", *configs[1].NextVersion)
+	assert.Equal(t, false, *configs[1].Branch)
+	assert.Equal(t, "git@github.com:fhopfensperger/web.git", configs[2].URL)
+	assert.Equal(t, []string{"frontend"}, configs[2].Groups)
+	assert.Equal(t, true, *configs[2].Tag)
+
+	assert.Equal(t, []string{"git@github.com:fhopfensperger/b.git"}, repoURLs(filterGroups(configs, []string{"backend"})))
+	assert.Len(t, filterGroups(configs, nil), 3)
+}
+
+func Test_readReposFile_plain_list(t *testing.T) {
+	repo1 := "https://github.com/fhopfensperger/amqp-sb-client.git"
+	repo2 := "git@github.com:fhopfensperger/json-log-to-human-readable.git"
+	fileName := writeFile(t, t.TempDir(), "repos.txt", repo1+"\n"+repo2+"\n")
+
+	configs, err := readReposFile(fileName)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{repo1, repo2}, repoURLs(configs))
+}
+
+func Test_readReposFile_ignore_empty_and_hashtag_lines(t *testing.T) {
+	repo1 := "https://github.com/fhopfensperger/amqp-sb-client.git"
+	repo3 := "https://github.com/fhopfensperger/json-log-to-human-readable.git"
+	repo4 := "#git@github.com:fhopfensperger/json-log-to-human-readable.git"
+	fileName := writeFile(t, t.TempDir(), "repos.txt", repo1+"\n\n"+repo3+"\n"+repo4+"\n")
+
+	configs, err := readReposFile(fileName)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{repo1, repo3}, repoURLs(configs))
+}
+
+func Test_readReposFile_missing(t *testing.T) {
+	configs, err := readReposFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+	assert.Nil(t, configs)
+}
+
+func Test_readReposFile_errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.txt", "!include b.txt\n")
+	writeFile(t, dir, "b.txt", "!include a.txt\n")
+	_, err := readReposFile(filepath.Join(dir, "a.txt"))
+	assert.ErrorContains(t, err, "include cycle")
+
+	_, err = readReposFile(writeFile(t, dir, "unknown.txt", "git@github.com:fhopfensperger/a.git colour=blue\n"))
+	assert.Error(t, err)
+
+	_, err = readReposFile(writeFile(t, dir, "invalid.txt", "git@github.com:fhopfensperger/a.git source\n"))
+	assert.ErrorContains(t, err, "line 1")
+
+	_, err = readReposFile(writeFile(t, dir, "bump.txt", "git@github.com:fhopfensperger/a.git bump=mayor\n"))
+	assert.ErrorContains(t, err, "mayor")
+
+	_, err = readReposFile(writeFile(t, dir, "include.txt", "!include\n"))
+	assert.Error(t, err)
+}
+
+func Test_readReposFile_manifest(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "repos.yaml", `
+repos:
+  - git@github.com:fhopfensperger/a.git
+  - url: git@github.com:fhopfensperger/b.git
+    nextversion: MAJOR
+    groups: [backend]
+`)
+	writeFile(t, dir, "repos.json", `{"repos": [{"url": "git@github.com:fhopfensperger/c.git", "tag": true}]}`)
+	writeFile(t, dir, "repos.txt", "[manifests]\n!include repos.json\n")
+
+	configs, err := readReposFile(yamlFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git@github.com:fhopfensperger/a.git", "git@github.com:fhopfensperger/b.git"}, repoURLs(configs))
+	assert.Equal(t, "MAJOR", *configs[1].NextVersion)
+	assert.Equal(t, []string{"backend"}, configs[1].Groups)
+
+	configs, err = readReposFile(filepath.Join(dir, "repos.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git@github.com:fhopfensperger/c.git"}, repoURLs(configs))
+	assert.Equal(t, []string{"manifests"}, configs[0].Groups)
+	assert.Equal(t, true, *configs[0].Tag)
+}
+
+func Test_readReposFile_stdin(t *testing.T) {
+	r := reposFileReader{stdin: strings.NewReader("git@github.com:fhopfensperger/a.git\n"), including: map[string]bool{}}
+	configs, err := r.read(stdinFileName, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"git@github.com:fhopfensperger/a.git"}, repoURLs(configs))
+}
@@ -17,8 +17,8 @@ limitations under the License.
 package cmd
 
 import (
-	"bufio"
 	"os"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 
@@ -28,11 +28,11 @@ import (
 )
 
 var repos []string
-var targetBranch string
-var sourceBranch string
 var fileName string
-var createTag bool
-var createBranch bool
+var cfgFile string
+
+// repoConfigs holds the repos list of the config file
+var repoConfigs []repoConfig
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -60,8 +60,9 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	//rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.git-releaser.yaml)")
 	pf := rootCmd.PersistentFlags()
+	pf.StringVar(&cfgFile, "config", "", "config file (default is $HOME/.git-releaser.yaml merged with ./.git-releaser.yaml)")
+
 	pf.StringSliceP("repos", "r", []string{}, "Git Repo urls e.g. git@github.com:fhopfensperger/my-repo.git")
 	_ = viper.BindPFlag("repos", pf.Lookup("repos"))
 
@@ -82,45 +83,55 @@ func init() {
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
-	pf.StringP("file", "f", "", "Use repos from file (one repo per line, line with a leading # will be ignored)")
+	pf.StringP("file", "f", "", "Use repos from file (one repo per line, # starts a comment), - reads from stdin, .yaml/.yml/.json files are read as manifest")
 	_ = viper.BindPFlag("file", pf.Lookup("file"))
+
+	pf.StringSlice("group", []string{}, "Only use the repos of the given groups of the repos file or config file")
+	_ = viper.BindPFlag("group", pf.Lookup("group"))
 	//rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	rootCmd.SetVersionTemplate(`{{printf "v%s\n" .Version}}`)
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
 
+	var err error
+	repoConfigs, err = readConfigFiles(viper.GetViper(), configFiles(), cfgFile != "")
+	if err != nil {
+		log.Err(err).Msg("")
+		os.Exit(1)
+	}
+	freezeWindows, err = parseFreezes(viper.Get("policy.freezes"))
+	if err != nil {
+		log.Err(err).Msg("")
+		os.Exit(1)
+	}
+
+	groups := viper.GetStringSlice("group")
 	repos = viper.GetStringSlice("repos")
-	sourceBranch = viper.GetString("source")
+	if len(repos) == 0 {
+		repos = repoURLs(filterGroups(repoConfigs, groups))
+	}
 	fileName = viper.GetString("file")
-	targetBranch = viper.GetString("target")
-	createBranch = viper.GetBool("branch")
-	createTag = viper.GetBool("tag")
 
 	if fileName != "" {
-		repos = getReposFromFile(fileName)
+		fileConfigs, err := readReposFile(fileName)
+		if err != nil {
+			log.Err(err).Msgf("Could not read repos from file %s", fileName)
+			os.Exit(1)
+		}
+		fileConfigs = filterGroups(fileConfigs, groups)
+		repos = repoURLs(fileConfigs)
+		repoConfigs = append(repoConfigs, fileConfigs...)
 	}
 }
 
-func getReposFromFile(fileName string) []string {
-	file, err := os.Open(fileName)
-	if err != nil {
-		log.Err(err).Msgf("Could not open file %s", fileName)
-		return nil
-	}
-	defer file.Close()
-
-	var lines []string
-
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) != 0 && string(line[0]) != "#" {
-			lines = append(lines, line)
-		}
+func repoURLs(configs []repoConfig) []string {
+	var urls []string
+	for _, rc := range configs {
+		urls = append(urls, rc.URL)
 	}
-	return lines
+	return urls
 }
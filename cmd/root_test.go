@@ -2,51 +2,15 @@ package cmd
 
 import (
 	"bytes"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
 
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_getReposFromFile(t *testing.T) {
-	repo1 := "https://github.com/fhopfensperger/amqp-sb-client.git"
-	repo2 := "git@github.com:fhopfensperger/json-log-to-human-readable.git"
-	fileName := "test.txt"
-	f, _ := os.Create(fileName)
-	f.WriteString(fmt.Sprintln(repo1))
-	f.WriteString(fmt.Sprintln(repo2))
-	repos := getReposFromFile(fileName)
-	assert.Equal(t, []string{repo1, repo2}, repos)
-
-	os.Remove(fileName)
-}
-
-func Test_getReposFromFile_ignore_empty_and_hashtag_lines(t *testing.T) {
-	repo1 := "https://github.com/fhopfensperger/amqp-sb-client.git"
-	repo2 := ""
-	repo3 := "https://github.com/fhopfensperger/json-log-to-human-readable.git"
-	repo4 := "#git@github.com:fhopfensperger/json-log-to-human-readable.git"
-	fileName := "test.txt"
-	f, _ := os.Create(fileName)
-	f.WriteString(fmt.Sprintln(repo1))
-	f.WriteString(fmt.Sprintln(repo2))
-	f.WriteString(fmt.Sprintln(repo3))
-	f.WriteString(fmt.Sprintln(repo4))
-	repos := getReposFromFile(fileName)
-	assert.Equal(t, []string{repo1, repo3}, repos)
-
-	os.Remove(fileName)
-}
-
-func Test_getReposFromFile_failed(t *testing.T) {
-	fileName := "test.txt"
-	repos := getReposFromFile(fileName)
-	assert.Equal(t, []string([]string(nil)), repos)
-
-}
-
 func Test_initConfig(t *testing.T) {
 	os.Setenv("REPOS", "repos123")
 
@@ -66,18 +30,29 @@ func TestExecute(t *testing.T) {
 }
 
 func TestExecute_repos_from_args(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
 	cmd := rootCmd
-	testRepos := []string{"git@github.com:fhopfensperger/git-releaser.git"}
+	testRepos := []string{r.URL()}
 	cmd.SetArgs([]string{"create", "-s", "main", "release", "-r", testRepos[0]})
 	Execute("0.0.0")
 
 	assert.Equal(t, repos, testRepos)
-	assert.Equal(t, targetBranch, "release")
+	opts, err := newReleaseOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, opts.targetBranch, "release")
 }
 
 func TestExecute_repos_from_args_not_existing(t *testing.T) {
 	cmd := rootCmd
-	testRepos := []string{"git@github.com:fhopfensperger/i-dont-exist.git"}
+	testRepos := []string{gittest.NewServer(t).URL("i-dont-exist")}
 	cmd.SetArgs([]string{"create", "-s", "main", "release", "-r", testRepos[0]})
 	Execute("0.0.0")
 }
+
+func Test_newReleaseOptions_invalid_template(t *testing.T) {
+	viper.Set("branch-template", "{{.Target}")
+	defer viper.Set("branch-template", nil)
+
+	_, err := newReleaseOptions()
+	assert.ErrorContains(t, err, "branch")
+}
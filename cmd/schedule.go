@@ -0,0 +1,354 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/schedule"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// stateFileName is the default state file of the schedule command, located in the home directory
+const stateFileName = ".git-releaser-state.json"
+
+// maxScheduleWait limits how long the scheduler sleeps at once, so changes of the system clock are noticed
+const maxScheduleWait = time.Minute
+
+// scheduleConfig is a single entry of the schedules list of the config file
+type scheduleConfig struct {
+	Name string `mapstructure:"name"`
+	Cron string `mapstructure:"cron"`
+	// Timezone the cron expression is evaluated in, defaults to the local time zone
+	Timezone string `mapstructure:"timezone"`
+	// Groups of the repos file or config file to release, all repos if empty
+	Groups []string `mapstructure:"groups"`
+	// NextVersion replaces the --nextversion default, the settings of the repos still take precedence
+	NextVersion *string `mapstructure:"nextversion"`
+}
+
+// scheduledRelease is a schedule with its parsed cron expression
+type scheduledRelease struct {
+	scheduleConfig
+	cron *schedule.Cron
+}
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Creates releases on the schedules of the config file",
+	Long: `Runs until interrupted and creates the releases of the schedules list of the config file, e.g. a weekly release train:
+
+schedules:
+  - name: release-train
+    cron: "0 10 * * TUE"
+    timezone: Europe/Berlin
+    groups: [backend]
+    nextversion: MINOR
+
+Every run releases the repos of the groups like create does, repos without changes are skipped.
+The last run of every schedule is stored in --state-file, so a restart neither repeats a run nor misses one within --catch-up.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// the release flags of schedule are only bound if schedule runs, otherwise they would replace the ones of create
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schedules, err := parseSchedules(viper.Get("schedules"))
+		if err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return errors.New("no schedules configured in the config file")
+		}
+		tmpl, err := notify.ParseTemplate(viper.GetString("notify-template"))
+		if err != nil {
+			return fmt.Errorf("invalid --notify-template: %w", err)
+		}
+
+		statePath := viper.GetString("state-file")
+		if statePath == "" {
+			statePath = defaultStatePath()
+		}
+		state, err := schedule.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+
+		s := newScheduler(schedules, state, statePath, viper.GetDuration("catch-up"))
+		// dry runs don't count as runs, otherwise the following real run would skip them
+		s.persist = !viper.GetBool("dry-run")
+		s.run = func(ctx context.Context, sc scheduleConfig) {
+			runScheduledRelease(ctx, sc, tmpl, cmd.Flags().Changed)
+		}
+		if viper.GetBool("list") {
+			s.start()
+			for _, sr := range s.schedules {
+				fmt.Printf("%s\t%s\t%s\n", sr.Name, sr.Cron, formatNextRun(s.next[sr.Name]))
+			}
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return s.loop(ctx)
+	},
+}
+
+func init() {
+	flags := scheduleCmd.Flags()
+	addReleaseFlags(flags)
+	addNotifyFlags(flags)
+	flags.Int("concurrency", 1, "How many repos should be released in parallel")
+	flags.String("state-file", "", "File storing the last run of every schedule, defaults to ~/"+stateFileName)
+	flags.Duration("catch-up", time.Hour, "Runs missed while not running are made up on start, if they are not older than this, 0 never makes up runs")
+	flags.Duration("total-timeout", 0, "Maximum duration of every scheduled run, 0 disables the timeout")
+	flags.Bool("list", false, "Only print the next run of every schedule")
+	rootCmd.AddCommand(scheduleCmd)
+}
+
+func defaultStatePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return stateFileName
+	}
+	return filepath.Join(home, stateFileName)
+}
+
+func formatNextRun(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// parseSchedules reads the schedules list of the config file
+func parseSchedules(raw interface{}) ([]scheduledRelease, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	entries, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schedules must be a list, got %T", raw)
+	}
+	names := map[string]bool{}
+	var schedules []scheduledRelease
+	for i, e := range entries {
+		var sc scheduleConfig
+		if err := mapstructure.Decode(e, &sc); err != nil {
+			return nil, fmt.Errorf("invalid schedules entry %d: %w", i+1, err)
+		}
+		// the name identifies the schedule in the state file
+		if sc.Name == "" {
+			return nil, fmt.Errorf("schedules entry %d has no name", i+1)
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("schedule %s is defined twice", sc.Name)
+		}
+		names[sc.Name] = true
+		loc := time.Local
+		if sc.Timezone != "" {
+			var err error
+			if loc, err = time.LoadLocation(sc.Timezone); err != nil {
+				return nil, fmt.Errorf("schedule %s has an invalid timezone: %w", sc.Name, err)
+			}
+		}
+		if sc.NextVersion != nil {
+			if _, ok := nextVersions[strings.ToUpper(*sc.NextVersion)]; !ok {
+				return nil, fmt.Errorf("schedule %s has an invalid nextversion %q, possible values: PATCH, MINOR, MAJOR", sc.Name, *sc.NextVersion)
+			}
+		}
+		c, err := schedule.Parse(sc.Cron, loc)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sc.Name, err)
+		}
+		schedules = append(schedules, scheduledRelease{scheduleConfig: sc, cron: c})
+	}
+	return schedules, nil
+}
+
+// scheduler runs every schedule when it is due
+type scheduler struct {
+	schedules []scheduledRelease
+	state     *schedule.State
+	statePath string
+	// catchUp is the maximum age of a missed run which is made up on start
+	catchUp time.Duration
+	// persist stores the runs in the state file
+	persist bool
+	// next run of every schedule by name, the zero time means never
+	next map[string]time.Time
+	// now and run are replaced in tests
+	now func() time.Time
+	run func(context.Context, scheduleConfig)
+}
+
+func newScheduler(schedules []scheduledRelease, state *schedule.State, statePath string, catchUp time.Duration) *scheduler {
+	return &scheduler{
+		schedules: schedules,
+		state:     state,
+		statePath: statePath,
+		catchUp:   catchUp,
+		persist:   true,
+		next:      map[string]time.Time{},
+		now:       time.Now,
+	}
+}
+
+// start calculates the first run of every schedule.
+// The latest run missed since the last recorded one is made up if it is not older than catchUp,
+// so a restart neither repeats nor misses a run. Several missed runs are made up only once.
+func (s *scheduler) start() {
+	now := s.now()
+	for _, sr := range s.schedules {
+		next := sr.cron.Next(now)
+		if last, ok := s.state.LastRuns[sr.Name]; ok {
+			missed := sr.cron.Next(last)
+			for n := missed; !n.IsZero() && !n.After(now); n = sr.cron.Next(n) {
+				missed = n
+			}
+			if !missed.IsZero() && !missed.After(now) {
+				if now.Sub(missed) <= s.catchUp {
+					log.Info().Msgf("Schedule %s missed its run at %s, running it now", sr.Name, missed.Format(time.RFC3339))
+					next = missed
+				} else {
+					log.Warn().Msgf("Schedule %s missed its run at %s, which is older than --catch-up %s", sr.Name, missed.Format(time.RFC3339), s.catchUp)
+				}
+			}
+		}
+		s.next[sr.Name] = next
+	}
+}
+
+// runDue runs all schedules which are due and returns the earliest next run, the zero time if no schedule runs again
+func (s *scheduler) runDue(ctx context.Context) time.Time {
+	for _, sr := range s.schedules {
+		due := s.next[sr.Name]
+		if due.IsZero() || due.After(s.now()) || ctx.Err() != nil {
+			continue
+		}
+		// the run is recorded before it starts, a crash during the run must not repeat it after the restart
+		if s.persist {
+			s.state.LastRuns[sr.Name] = due
+			if err := s.state.Save(s.statePath); err != nil {
+				log.Err(err).Msgf("Skipping the run of schedule %s, as its state could not be saved", sr.Name)
+				s.next[sr.Name] = sr.cron.Next(s.now())
+				continue
+			}
+		}
+		s.run(ctx, sr.scheduleConfig)
+		s.next[sr.Name] = sr.cron.Next(s.now())
+	}
+
+	var earliest time.Time
+	for _, next := range s.next {
+		if !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// loop runs the schedules until ctx is cancelled
+func (s *scheduler) loop(ctx context.Context) error {
+	s.start()
+	for {
+		next := s.runDue(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if next.IsZero() {
+			return errors.New("no schedule will ever run again")
+		}
+		log.Debug().Msgf("Next scheduled run at %s", next.Format(time.RFC3339))
+		wait := next.Sub(s.now())
+		if wait > maxScheduleWait {
+			wait = maxScheduleWait
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Info().Msg("Stopping scheduler")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// scheduledTargets returns the repos of the groups of sc with their release options
+func scheduledTargets(ctx context.Context, sc scheduleConfig, flagChanged func(string) bool) ([]releaseTarget, error) {
+	urls := repos
+	if len(sc.Groups) > 0 {
+		urls = repoURLs(filterGroups(repoConfigs, sc.Groups))
+	} else {
+		// repos are discovered on every run, so new repos of the organization are released as well
+		discovered, err := discoverRepos(ctx)
+		if err != nil {
+			return nil, err
+		}
+		urls = appendUnique(urls, discovered...)
+	}
+	opts, err := newReleaseOptions()
+	if err != nil {
+		return nil, err
+	}
+	if sc.NextVersion != nil && !flagChanged("nextversion") {
+		if opts.nextVersion, err = setNextVersion(*sc.NextVersion); err != nil {
+			return nil, err
+		}
+	}
+	return releaseTargets(urls, opts, repoConfigs, flagChanged), nil
+}
+
+// runScheduledRelease releases the repos of sc, once ctx is cancelled no further repos are started
+func runScheduledRelease(ctx context.Context, sc scheduleConfig, tmpl *template.Template, flagChanged func(string) bool) {
+	log.Info().Msgf("Starting scheduled release %s", sc.Name)
+	targets, err := scheduledTargets(ctx, sc, flagChanged)
+	if err != nil {
+		log.Err(err).Msgf("Scheduled release %s failed", sc.Name)
+		return
+	}
+	if len(targets) == 0 {
+		log.Warn().Msgf("Schedule %s has no repos in groups %v", sc.Name, sc.Groups)
+		return
+	}
+	// running releases are completed on shutdown, only --total-timeout aborts them
+	runCtx := context.WithoutCancel(ctx)
+	if totalTimeout := viper.GetDuration("total-timeout"); totalTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, totalTimeout)
+		defer cancel()
+	}
+	results := releaseRepos(runCtx, ctx.Done(), targets, viper.GetInt("concurrency"), createRelease)
+	logSummary(results)
+	if !viper.GetBool("dry-run") {
+		sendNotifications(results, tmpl, notifiers())
+	}
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/schedule"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScheduler returns a scheduler with a daily schedule at 10:00 UTC, the clock is set by *now
+func testScheduler(t *testing.T, statePath string, now *time.Time) (*scheduler, *[]string) {
+	schedules, err := parseSchedules([]interface{}{
+		map[string]interface{}{"name": "train", "cron": "0 10 * * *", "timezone": "UTC", "groups": []string{"backend"}},
+	})
+	require.NoError(t, err)
+	state, err := schedule.LoadState(statePath)
+	require.NoError(t, err)
+
+	var runs []string
+	s := newScheduler(schedules, state, statePath, time.Hour)
+	s.now = func() time.Time { return *now }
+	s.run = func(ctx context.Context, sc scheduleConfig) {
+		runs = append(runs, sc.Name+" "+now.Format(time.RFC3339))
+	}
+	s.start()
+	return s, &runs
+}
+
+func Test_scheduler(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	slot := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	now := slot.Add(-time.Hour)
+	s, runs := testScheduler(t, statePath, &now)
+
+	assert.Equal(t, slot, s.runDue(context.Background()))
+	assert.Empty(t, *runs)
+
+	now = slot.Add(20 * time.Second)
+	assert.Equal(t, slot.AddDate(0, 0, 1), s.runDue(context.Background()))
+	assert.Equal(t, []string{"train 2026-10-14T10:00:20Z"}, *runs)
+	s.runDue(context.Background())
+	assert.Len(t, *runs, 1)
+
+	state, err := schedule.LoadState(statePath)
+	require.NoError(t, err)
+	assert.True(t, slot.Equal(state.LastRuns["train"]))
+
+	// a restart within the same minute doesn't repeat the run
+	now = slot.Add(40 * time.Second)
+	s, runs = testScheduler(t, statePath, &now)
+	assert.Equal(t, slot.AddDate(0, 0, 1), s.runDue(context.Background()))
+	assert.Empty(t, *runs)
+}
+
+func Test_scheduler_catch_up(t *testing.T) {
+	slot := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	state := &schedule.State{LastRuns: map[string]time.Time{"train": slot.AddDate(0, 0, -3)}}
+	require.NoError(t, state.Save(statePath))
+
+	// the latest missed run is made up once
+	now := slot.Add(30 * time.Minute)
+	s, runs := testScheduler(t, statePath, &now)
+	s.runDue(context.Background())
+	assert.Equal(t, []string{"train 2026-10-14T10:30:00Z"}, *runs)
+
+	// runs older than --catch-up are skipped
+	require.NoError(t, state.Save(statePath))
+	now = slot.Add(2 * time.Hour)
+	s, runs = testScheduler(t, statePath, &now)
+	assert.Equal(t, slot.AddDate(0, 0, 1), s.runDue(context.Background()))
+	assert.Empty(t, *runs)
+}
+
+func Test_scheduler_dry_run(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	slot := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	now := slot.Add(-time.Minute)
+	s, runs := testScheduler(t, statePath, &now)
+	s.persist = false
+
+	now = slot
+	s.runDue(context.Background())
+
+	assert.Len(t, *runs, 1)
+	assert.NoFileExists(t, statePath)
+}
+
+func Test_parseSchedules(t *testing.T) {
+	schedules, err := parseSchedules([]interface{}{
+		map[string]interface{}{"name": "train", "cron": "0 10 * * TUE", "timezone": "Europe/Berlin", "groups": []interface{}{"backend"}, "nextversion": "MINOR"},
+		map[string]interface{}{"name": "nightly", "cron": "@daily"},
+	})
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, []string{"backend"}, schedules[0].Groups)
+	assert.Equal(t, "MINOR", *schedules[0].NextVersion)
+	assert.Nil(t, schedules[1].NextVersion)
+
+	for name, raw := range map[string]interface{}{
+		"no list":          "0 10 * * *",
+		"no name":          []interface{}{map[string]interface{}{"cron": "@daily"}},
+		"duplicate name":   []interface{}{map[string]interface{}{"name": "a", "cron": "@daily"}, map[string]interface{}{"name": "a", "cron": "@hourly"}},
+		"invalid cron":     []interface{}{map[string]interface{}{"name": "a", "cron": "0 25 * * *"}},
+		"invalid timezone": []interface{}{map[string]interface{}{"name": "a", "cron": "@daily", "timezone": "Mars/Olympus"}},
+	} {
+		_, err := parseSchedules(raw)
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_runScheduledRelease(t *testing.T) {
+	backend := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	upToDate := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"))
+	frontend := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+
+	oldConfigs, oldRepos := repoConfigs, repos
+	defer func() { repoConfigs, repos = oldConfigs, oldRepos }()
+	repoConfigs = []repoConfig{
+		{URL: backend.URL(), Groups: []string{"backend"}},
+		{URL: upToDate.URL(), Groups: []string{"backend"}},
+		{URL: frontend.URL(), Groups: []string{"frontend"}},
+	}
+	repos = repoURLs(repoConfigs)
+	for k, v := range map[string]interface{}{"source": "main", "tag": true, "nextversion": "PATCH"} {
+		viper.Set(k, v)
+		defer viper.Set(k, nil)
+	}
+
+	minor := "MINOR"
+	tmpl, _ := notify.ParseTemplate("")
+	runScheduledRelease(context.Background(), scheduleConfig{Name: "train", Groups: []string{"backend"}, NextVersion: &minor}, tmpl, func(string) bool { return false })
+
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, backend.Tags())
+	assert.Equal(t, []string{"v1.0.0"}, upToDate.Tags(), "nothing to release")
+	assert.Equal(t, []string{"v1.0.0"}, frontend.Tags(), "not in the group")
+}
+
+func Test_runScheduledRelease_total_timeout(t *testing.T) {
+	backend := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+
+	oldConfigs, oldRepos := repoConfigs, repos
+	defer func() { repoConfigs, repos = oldConfigs, oldRepos }()
+	repoConfigs = []repoConfig{{URL: backend.URL(), Groups: []string{"backend"}}}
+	repos = repoURLs(repoConfigs)
+	for k, v := range map[string]interface{}{"source": "main", "tag": true, "nextversion": "PATCH", "total-timeout": time.Nanosecond} {
+		viper.Set(k, v)
+		defer viper.Set(k, nil)
+	}
+
+	tmpl, _ := notify.ParseTemplate("")
+	runScheduledRelease(context.Background(), scheduleConfig{Name: "train", Groups: []string{"backend"}}, tmpl, func(string) bool { return false })
+
+	assert.Equal(t, []string{"v1.0.0"}, backend.Tags())
+}
+
+func Test_scheduledTargets_nextversion(t *testing.T) {
+	oldConfigs := repoConfigs
+	defer func() { repoConfigs = oldConfigs }()
+	patch := "PATCH"
+	repoConfigs = []repoConfig{
+		{URL: "git@github.com:acme/api.git", Groups: []string{"backend"}},
+		{URL: "git@github.com:acme/db.git", Groups: []string{"backend"}, NextVersion: &patch},
+	}
+	major := "MAJOR"
+	sc := scheduleConfig{Name: "train", Groups: []string{"backend"}, NextVersion: &major}
+
+	targets, err := scheduledTargets(context.Background(), sc, func(string) bool { return false })
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.Equal(t, repo.MAJOR, targets[0].opts.nextVersion)
+	assert.Equal(t, repo.PATCH, targets[1].opts.nextVersion, "the setting of the repo takes precedence")
+
+	// an explicit --nextversion overrides the schedule and the repos
+	viper.Set("nextversion", "MINOR")
+	defer viper.Set("nextversion", nil)
+	targets, err = scheduledTargets(context.Background(), sc, func(name string) bool { return name == "nextversion" })
+	require.NoError(t, err)
+	assert.Equal(t, repo.MINOR, targets[0].opts.nextVersion)
+	assert.Equal(t, repo.MINOR, targets[1].opts.nextVersion)
+}
@@ -0,0 +1,382 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/webhook"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// maxJobs is the number of recent jobs kept for the jobs API
+const maxJobs = 100
+
+// defaultQueueSize is the default number of waiting releases per repo
+const defaultQueueSize = 10
+
+// errQueueFull is returned by enqueue if too many releases of a repo are waiting
+var errQueueFull = errors.New("too many releases of the repo are waiting")
+
+// shutdownTimeout limits waiting for open requests on shutdown, running releases are always awaited
+const shutdownTimeout = 30 * time.Second
+
+// Job statuses
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobSucceeded = "succeeded"
+	jobFailed    = "failed"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Releases repos on push or merge webhooks",
+	Long: `Runs an HTTP server receiving push and merge webhooks of GitHub, GitLab and Gitea on /webhook.
+A push to or a merge into the source branch of a configured repo creates a new release, like create does.
+Releases of the same repo are queued and run one after another, /jobs lists the recent releases and /healthz can be used as health check.`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// the release flags of serve are only bound if serve runs, otherwise they would replace the ones of create
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(repos) == 0 && fileName == "" && viper.GetString("org") == "" && viper.GetString("gitlab-group") == "" {
+			return errors.New("either -f (file), -r (repos), --org or --gitlab-group must be set")
+		}
+		tmpl, err := notify.ParseTemplate(viper.GetString("notify-template"))
+		if err != nil {
+			return fmt.Errorf("invalid --notify-template: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		discovered, err := discoverRepos(ctx)
+		if err != nil {
+			return err
+		}
+		opts, err := newReleaseOptions()
+		if err != nil {
+			return err
+		}
+		targets := releaseTargets(appendUnique(repos, discovered...), opts, repoConfigs, cmd.Flags().Changed)
+
+		secret, err := webhookSecret()
+		if err != nil {
+			return err
+		}
+		srv := newReleaseServer(targets, secret, notifiers(), tmpl)
+		srv.queueSize = viper.GetInt("queue-size")
+		httpServer := &http.Server{Addr: viper.GetString("listen"), Handler: srv.routes(), ReadHeaderTimeout: 10 * time.Second}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- httpServer.ListenAndServe()
+		}()
+		log.Info().Msgf("Listening on %s for webhooks of %d repos", httpServer.Addr, len(targets))
+
+		select {
+		case err := <-serveErr:
+			return err
+		case <-ctx.Done():
+		}
+		log.Info().Msg("Shutting down, waiting for running releases to finish")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("")
+		}
+		srv.wait()
+		return nil
+	},
+}
+
+func init() {
+	flags := serveCmd.Flags()
+	addReleaseFlags(flags)
+	addNotifyFlags(flags)
+	flags.String("listen", ":8080", "Address the HTTP server listens on")
+	flags.String("webhook-secret", "", "Secret of the webhooks, used to verify the GitHub and Gitea signatures and the GitLab token")
+	flags.Bool("insecure-no-signature", false, "Accept webhooks without verifying their signature if no --webhook-secret is set")
+	flags.Int("queue-size", defaultQueueSize, "Maximum number of waiting releases per repo, further webhooks are rejected with 429")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// webhookSecret returns the secret to verify webhooks with,
+// serving unsigned webhooks has to be enabled explicitly as everyone reaching the server could trigger releases.
+func webhookSecret() (string, error) {
+	secret := viper.GetString("webhook-secret")
+	if secret != "" {
+		return secret, nil
+	}
+	if !viper.GetBool("insecure-no-signature") {
+		return "", errors.New("--webhook-secret must be set, use --insecure-no-signature to accept unsigned webhooks")
+	}
+	log.Warn().Msg("No --webhook-secret set, webhook signatures are not verified")
+	return "", nil
+}
+
+// releaseJob is a release triggered by a webhook
+type releaseJob struct {
+	ID       int              `json:"id"`
+	RepoURL  string           `json:"repo_url"`
+	Event    string           `json:"event"`
+	Status   string           `json:"status"`
+	Queued   time.Time        `json:"queued_at"`
+	Started  *time.Time       `json:"started_at,omitempty"`
+	Finished *time.Time       `json:"finished_at,omitempty"`
+	Result   *releaser.Result `json:"result,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// releaseServer runs a release for every webhook of a configured repo
+type releaseServer struct {
+	// targets by webhook.RepoKey
+	targets   map[string]releaseTarget
+	secret    string
+	notifiers []notify.Notifier
+	tmpl      *template.Template
+	// release is replaced in tests
+	release func(context.Context, string, releaseOptions, *zerolog.Logger) (releaser.Result, error)
+
+	// queueSize limits the waiting releases of every repo
+	queueSize int
+
+	mu     sync.Mutex
+	jobs   []*releaseJob
+	nextID int
+	// queues hold the waiting releases by webhook.RepoKey, every repo is released by at most one worker
+	queues  map[string]*releaseQueue
+	running sync.WaitGroup
+}
+
+// releaseQueue holds the waiting releases of a repo
+type releaseQueue struct {
+	pending []queuedRelease
+	// working is true while a worker releases the repo
+	working bool
+}
+
+type queuedRelease struct {
+	job    *releaseJob
+	target releaseTarget
+}
+
+func newReleaseServer(targets []releaseTarget, secret string, n []notify.Notifier, tmpl *template.Template) *releaseServer {
+	s := &releaseServer{
+		targets:   make(map[string]releaseTarget, len(targets)),
+		secret:    secret,
+		notifiers: n,
+		tmpl:      tmpl,
+		release:   releaseRepo,
+		queueSize: defaultQueueSize,
+		queues:    map[string]*releaseQueue{},
+	}
+	for _, t := range targets {
+		if _, ok := s.targets[webhook.RepoKey(t.repoURL)]; !ok {
+			s.targets[webhook.RepoKey(t.repoURL)] = t
+		}
+	}
+	return s
+}
+
+func (s *releaseServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("POST /webhook", s.handleWebhook)
+	mux.HandleFunc("GET /jobs", s.handleJobs)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	return mux
+}
+
+func (s *releaseServer) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, webhook.MaxBodySize)
+	evt, err := webhook.Parse(r, s.secret)
+	switch {
+	case errors.Is(err, webhook.ErrInvalidSignature):
+		log.Warn().Msgf("Rejected webhook from %s: %v", r.RemoteAddr, err)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, webhook.ErrIgnored):
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	target, ok := s.target(evt)
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": fmt.Sprintf("no release configured for %v on branch %s", evt.RepoURLs, evt.Branch)})
+		return
+	}
+	job, err := s.enqueue(target, evt.String())
+	if err != nil {
+		log.Warn().Msgf("Rejected webhook for %s: %v", target.repoURL, err)
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// target returns the release target of the repository of evt, if evt changed its source branch
+func (s *releaseServer) target(evt webhook.Event) (releaseTarget, bool) {
+	for _, u := range evt.RepoURLs {
+		if t, ok := s.targets[webhook.RepoKey(u)]; ok && t.opts.sourceBranch == evt.Branch {
+			return t, true
+		}
+	}
+	return releaseTarget{}, false
+}
+
+// enqueue queues the release of target and returns a copy of the new job,
+// errQueueFull is returned if queueSize releases of the repo are already waiting.
+func (s *releaseServer) enqueue(target releaseTarget, event string) (releaseJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := webhook.RepoKey(target.repoURL)
+	q := s.queues[key]
+	if q == nil {
+		q = &releaseQueue{}
+		s.queues[key] = q
+	}
+	if len(q.pending) >= s.queueSize {
+		return releaseJob{}, errQueueFull
+	}
+	s.nextID++
+	job := &releaseJob{ID: s.nextID, RepoURL: target.repoURL, Event: event, Status: jobQueued, Queued: time.Now()}
+	s.jobs = append(s.jobs, job)
+	if len(s.jobs) > maxJobs {
+		s.jobs = s.jobs[len(s.jobs)-maxJobs:]
+	}
+	q.pending = append(q.pending, queuedRelease{job: job, target: target})
+	if !q.working {
+		q.working = true
+		s.running.Add(1)
+		go s.work(q)
+	}
+	return *job, nil
+}
+
+// work runs the releases of q one after another until q is empty
+func (s *releaseServer) work(q *releaseQueue) {
+	defer s.running.Done()
+	for {
+		s.mu.Lock()
+		if len(q.pending) == 0 {
+			q.working = false
+			s.mu.Unlock()
+			return
+		}
+		next := q.pending[0]
+		q.pending = q.pending[1:]
+		s.mu.Unlock()
+		s.run(next.job, next.target)
+	}
+}
+
+func (s *releaseServer) run(job *releaseJob, target releaseTarget) {
+	s.update(job, func(j *releaseJob) {
+		now := time.Now()
+		j.Status, j.Started = jobRunning, &now
+	})
+	logger := log.With().Str(RepoFieldName, target.repoURL).Logger()
+	logger.Info().Msgf("Starting release triggered by %s", job.Event)
+	res, err := s.release(context.Background(), target.repoURL, target.opts, &logger)
+	if err != nil {
+		logger.Err(err).Msg("Release failed")
+	} else {
+		logger.Info().Msgf("Successfully completed, %s", describeRelease(res))
+	}
+	s.update(job, func(j *releaseJob) {
+		now := time.Now()
+		j.Finished, j.Result = &now, &res
+		j.Status = jobSucceeded
+		if err != nil {
+			j.Status, j.Error = jobFailed, err.Error()
+		}
+	})
+	if !target.opts.dryRun {
+		sendNotifications([]releaseResult{{repoURL: target.repoURL, outcome: releaseOutcome(res), err: err}}, s.tmpl, s.notifiers)
+	}
+}
+
+func (s *releaseServer) update(job *releaseJob, f func(*releaseJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(job)
+}
+
+// recentJobs returns copies of the recent jobs, newest first
+func (s *releaseServer) recentJobs() []releaseJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]releaseJob, 0, len(s.jobs))
+	for i := len(s.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, *s.jobs[i])
+	}
+	return jobs
+}
+
+func (s *releaseServer) handleJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.recentJobs())
+}
+
+func (s *releaseServer) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid job id"})
+		return
+	}
+	for _, job := range s.recentJobs() {
+		if job.ID == id {
+			writeJSON(w, http.StatusOK, job)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"error": "job not found"})
+}
+
+// wait blocks until all queued releases are finished
+func (s *releaseServer) wait() {
+	s.running.Wait()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
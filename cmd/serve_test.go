@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pushEvent(repoURL, ref string) string {
+	return fmt.Sprintf(`{"ref": %q, "repository": {"clone_url": %q}}`, ref, repoURL)
+}
+
+func postWebhook(t *testing.T, handler http.Handler, body string) (*httptest.ResponseRecorder, releaseJob) {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	r.Header.Set("X-GitHub-Event", "push")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	var job releaseJob
+	if w.Code == http.StatusAccepted {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	}
+	return w, job
+}
+
+func Test_releaseServer(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"), gittest.Commit("feature"), gittest.Branch("main"))
+	tmpl, _ := notify.ParseTemplate("")
+	srv := newReleaseServer([]releaseTarget{{repoURL: r.URL(), opts: releaseOptions{sourceBranch: "main", createTag: true, nextVersion: repo.PATCH}}}, "", nil, tmpl)
+	handler := srv.routes()
+
+	w, job := postWebhook(t, handler, pushEvent(r.URL(), "refs/heads/main"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, 1, job.ID)
+	assert.Equal(t, r.URL(), job.RepoURL)
+	assert.Equal(t, "github push to main", job.Event)
+	srv.wait()
+	assert.Equal(t, []string{"v1.0.0", "v1.0.1"}, r.Tags())
+
+	// pushes of other branches, tags and unknown repos don't create a release
+	for _, body := range []string{
+		pushEvent(r.URL(), "refs/heads/feature"),
+		pushEvent(r.URL(), "refs/tags/v1.0.1"),
+		pushEvent("https://github.com/acme/unknown.git", "refs/heads/main"),
+	} {
+		w, _ = postWebhook(t, handler, body)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "ignored")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	var jobs []releaseJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jobs))
+	require.Len(t, jobs, 1)
+	assert.Equal(t, jobSucceeded, jobs[0].Status)
+	assert.Equal(t, "v1.0.1", jobs[0].Result.NextVersion)
+	assert.NotNil(t, jobs[0].Finished)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_releaseServer_signature(t *testing.T) {
+	tmpl, _ := notify.ParseTemplate("")
+	srv := newReleaseServer([]releaseTarget{{repoURL: "git@github.com:acme/api.git", opts: releaseOptions{sourceBranch: "main"}}}, "s3cret", nil, tmpl)
+
+	w, _ := postWebhook(t, srv.routes(), pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, srv.recentJobs())
+}
+
+func Test_releaseServer_serializes_releases_per_repo(t *testing.T) {
+	tmpl, _ := notify.ParseTemplate("")
+	targets := []releaseTarget{
+		{repoURL: "git@github.com:acme/api.git", opts: releaseOptions{sourceBranch: "main"}},
+		{repoURL: "git@github.com:acme/web.git", opts: releaseOptions{sourceBranch: "main"}},
+	}
+	srv := newReleaseServer(targets, "", nil, tmpl)
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	srv.release = func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (releaser.Result, error) {
+		mu.Lock()
+		running[repoURL]++
+		if running[repoURL] > maxRunning[repoURL] {
+			maxRunning[repoURL] = running[repoURL]
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running[repoURL]--
+		mu.Unlock()
+		return releaser.Result{RepoURL: repoURL, Created: true}, nil
+	}
+
+	handler := srv.routes()
+	for i := 0; i < 3; i++ {
+		// the ssh url of the config matches the https url of the webhook
+		postWebhook(t, handler, pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+		postWebhook(t, handler, pushEvent("https://github.com/acme/web", "refs/heads/main"))
+	}
+	srv.wait()
+
+	assert.Equal(t, map[string]int{"git@github.com:acme/api.git": 1, "git@github.com:acme/web.git": 1}, maxRunning)
+	jobs := srv.recentJobs()
+	assert.Len(t, jobs, 6)
+	assert.Equal(t, 6, jobs[0].ID, "newest first")
+	for _, job := range jobs {
+		assert.Equal(t, jobSucceeded, job.Status)
+	}
+}
+
+func Test_releaseServer_queue_full(t *testing.T) {
+	tmpl, _ := notify.ParseTemplate("")
+	srv := newReleaseServer([]releaseTarget{{repoURL: "git@github.com:acme/api.git", opts: releaseOptions{sourceBranch: "main"}}}, "", nil, tmpl)
+	srv.queueSize = 1
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv.release = func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (releaser.Result, error) {
+		started <- struct{}{}
+		<-release
+		return releaser.Result{RepoURL: repoURL, Created: true}, nil
+	}
+	handler := srv.routes()
+
+	w, _ := postWebhook(t, handler, pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	<-started
+	// the running release doesn't count, a second one can wait
+	w, _ = postWebhook(t, handler, pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	w, _ = postWebhook(t, handler, pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	srv.wait()
+	jobs := srv.recentJobs()
+	assert.Len(t, jobs, 2)
+	for _, job := range jobs {
+		assert.Equal(t, jobSucceeded, job.Status)
+	}
+
+	// the worker has stopped, new webhooks start a new one
+	w, _ = postWebhook(t, handler, pushEvent("https://github.com/acme/api.git", "refs/heads/main"))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	<-started
+	release <- struct{}{}
+	srv.wait()
+}
+
+func Test_webhookSecret(t *testing.T) {
+	_, err := webhookSecret()
+	assert.ErrorContains(t, err, "--insecure-no-signature")
+
+	viper.Set("insecure-no-signature", true)
+	defer viper.Set("insecure-no-signature", nil)
+	secret, err := webhookSecret()
+	assert.NoError(t, err)
+	assert.Empty(t, secret)
+
+	viper.Set("webhook-secret", "s3cret")
+	defer viper.Set("webhook-secret", nil)
+	secret, err = webhookSecret()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", secret)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2021 Florian Hopfensperger <f.hopfensperger@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/notify"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// yankCmd represents the yank command
+var yankCmd = &cobra.Command{
+	Use:   "yank <version>",
+	Short: "Deletes the tag and release branch of a bad release like v1.0.5",
+	Long: `Deletes the tag and the release branch of a bad release like v1.0.5 with a single push, release branches of a line
+like release/v1.0 are kept. --record records the yank as reference refs/yanked/v1.0.5, so the version is never released again.
+For Go modules the retract directive for go.mod is proposed, because the module proxy keeps serving deleted tags.`,
+	Args: cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return viper.BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yank := releaser.YankOptions{Version: args[0], Record: viper.GetBool("record")}
+		return runFlow(cmd, yankOperation(yank))
+	},
+}
+
+func init() {
+	flags := yankCmd.Flags()
+	addRepoFlags(flags)
+	flags.Bool("dry-run", false, "Only show which release branches and tags would be deleted, without pushing anything")
+	addNotifyFlags(flags)
+	flags.Bool("record", false, "Record the yank as reference refs/yanked/<version>, so the version is never released again")
+	flags.Int("concurrency", 1, "How many repos should be yanked in parallel")
+	addRunFlags(flags)
+	rootCmd.AddCommand(yankCmd)
+}
+
+// yankOperation returns the operation yanking the release of yank
+func yankOperation(yank releaser.YankOptions) operation {
+	return func(ctx context.Context, repoURL string, opts releaseOptions, logger *zerolog.Logger) (outcome, error) {
+		ro, err := opts.releaserOptions(repoURL, logger)
+		if err != nil {
+			return yankOutcome{RepoURL: repoURL}, err
+		}
+		yank.DryRun = opts.dryRun
+		res, err := releaser.Yank(ctx, repoURL, ro, yank)
+		return yankOutcome(res), err
+	}
+}
+
+// yankOutcome is the outcome of yank
+type yankOutcome releaser.YankResult
+
+func (y yankOutcome) describe() string {
+	var s string
+	switch {
+	case y.UpToDate:
+		s = fmt.Sprintf("nothing to do, %s is already yanked", y.Version)
+	case y.Yanked:
+		s = fmt.Sprintf("yanked %s, deleted %s", y.Version, strings.Join(y.Deleted, ", "))
+	default:
+		s = fmt.Sprintf("would yank %s, deleting %s", y.Version, strings.Join(y.Deleted, ", "))
+	}
+	if y.Retract != "" {
+		s += fmt.Sprintf(", add `%s` to go.mod", y.Retract)
+	}
+	return s
+}
+
+func (y yankOutcome) pending() bool {
+	return !y.UpToDate
+}
+
+func (y yankOutcome) notification() notify.Release {
+	res := releaser.YankResult(y)
+	n := notify.Release{Yank: &res, Status: notify.StatusPlanned}
+	switch {
+	case y.Yanked:
+		n.Status = notify.StatusYanked
+	case y.UpToDate:
+		n.Status = notify.StatusUpToDate
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_yankOperation(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.CommitFile("initial", "go.mod", "module github.com/acme/api\n"), gittest.Branch("main"), gittest.Tag("v1.0.4"),
+		gittest.CommitFile("bad", "CHANGES", "bad\n"), gittest.Tag("v1.0.5"), gittest.Branch("release/v1.0.5"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	// see yankCmd
+	yank := yankOperation(releaser.YankOptions{Version: "v1.0.5", Record: true})
+	opts := releaseOptions{targetBranch: "release", dryRun: true}
+
+	got, err := yank(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "would yank v1.0.5, deleting release/v1.0.5, v1.0.5, add `retract v1.0.5` to go.mod", got.describe())
+	assert.Equal(t, []string{"v1.0.4", "v1.0.5"}, r.Tags())
+
+	opts.dryRun = false
+	got, err = yank(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "yanked v1.0.5, deleted release/v1.0.5, v1.0.5, add `retract v1.0.5` to go.mod", got.describe())
+	assert.Equal(t, []string{"v1.0.4"}, r.Tags())
+	assert.Equal(t, []string{"main"}, r.Branches())
+
+	got, err = yank(ctx, r.URL(), opts, &log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "nothing to do, v1.0.5 is already yanked", got.describe())
+}
+
+func Test_createNewReleaseVersion_after_yank(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.4"),
+		gittest.CommitFile("bad", "CHANGES", "bad\n"), gittest.Tag("v1.0.5"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	_, err := releaser.Yank(ctx, r.URL(), releaser.Options{}, releaser.YankOptions{Version: "v1.0.5", Record: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1.0.4"}, r.Tags())
+
+	// the default flags, i.e. -n PATCH and --max-jump 1
+	opts, err := newReleaseOptions()
+	require.NoError(t, err)
+	opts.sourceBranch, opts.createTag = "main", true
+	got, err := createNewReleaseVersion(ctx, r.URL(), opts, &log.Logger)
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.6", got.NextVersion)
+	assert.Equal(t, []string{"v1.0.5"}, got.SkippedVersions)
+	assert.Equal(t, []string{"v1.0.4", "v1.0.6"}, r.Tags())
+}
+
+func Test_confirm_yank(t *testing.T) {
+	var targets []releaseTarget
+	var rs []*gittest.Repo
+	for i := 0; i < 2; i++ {
+		r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Tag("v1.0.5"), gittest.Branch("main"))
+		rs = append(rs, r)
+		targets = append(targets, releaseTarget{repoURL: r.URL(), opts: releaseOptions{targetBranch: "release", dryRun: true}})
+	}
+	planned := releaseRepos(context.Background(), nil, targets, 1, yankOperation(releaser.YankOptions{Version: "v1.0.5"}))
+
+	var out bytes.Buffer
+	err := confirm(planned, 1, strings.NewReader("n\n"), &out, true)
+
+	assert.ErrorIs(t, err, errNotConfirmed)
+	assert.Contains(t, out.String(), rs[0].URL()+": would yank v1.0.5, deleting v1.0.5")
+	for _, r := range rs {
+		assert.Equal(t, []string{"v1.0.5"}, r.Tags())
+	}
+}
+
+func Test_yankCmd_flags(t *testing.T) {
+	// yank doesn't create releases, so the flags of new releases aren't offered
+	for _, name := range []string{"pre-release", "post-release", "allow-major", "max-jump", "line", "force", "branch-granularity"} {
+		assert.Nil(t, yankCmd.Flags().Lookup(name), name)
+	}
+	assert.Contains(t, yankCmd.Flags().Lookup("dry-run").Usage, "deleted")
+}
@@ -16,7 +16,9 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fhopfensperger/git-releaser/cmd"
@@ -35,5 +37,17 @@ func main() {
 }
 
 func setupLogger() {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339, FormatPrepare: prefixRepo})
+}
+
+// prefixRepo moves the repo field in front of the message, so interleaved output of concurrent releases stays readable
+func prefixRepo(evt map[string]interface{}) error {
+	r, ok := evt[cmd.RepoFieldName]
+	if !ok {
+		return nil
+	}
+	delete(evt, cmd.RepoFieldName)
+	msg, _ := evt[zerolog.MessageFieldName].(string)
+	evt[zerolog.MessageFieldName] = strings.TrimSpace(fmt.Sprintf("[%s] %s", r, msg))
+	return nil
 }
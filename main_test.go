@@ -13,3 +13,13 @@ func Test_main(t *testing.T) {
 	main()
 	assert.Equal(t, 1, 1)
 }
+
+func Test_prefixRepo(t *testing.T) {
+	evt := map[string]interface{}{"repo": "git@github.com:fhopfensperger/my-repo.git", "message": "Successfully completed"}
+	assert.NoError(t, prefixRepo(evt))
+	assert.Equal(t, map[string]interface{}{"message": "[git@github.com:fhopfensperger/my-repo.git] Successfully completed"}, evt)
+
+	evt = map[string]interface{}{"message": "Summary:"}
+	assert.NoError(t, prefixRepo(evt))
+	assert.Equal(t, map[string]interface{}{"message": "Summary:"}, evt)
+}
@@ -0,0 +1,109 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+const perPage = 100
+
+// Repository is a repository found on a forge like GitHub or GitLab
+type Repository struct {
+	Name       string
+	FullName   string
+	SSHURL     string
+	HTTPURL    string
+	Topics     []string
+	Visibility string
+	Archived   bool
+}
+
+// CloneURL returns the ssh url, or the https url if useHTTPS is set
+func (r Repository) CloneURL(useHTTPS bool) string {
+	if useHTTPS {
+		return r.HTTPURL
+	}
+	return r.SSHURL
+}
+
+// Filter restricts the discovered repositories, the zero value keeps all non archived repositories
+type Filter struct {
+	// Topics keeps repositories having at least one of the topics
+	Topics []string
+	// Name keeps repositories whose name matches the regex
+	Name *regexp.Regexp
+	// Visibility keeps repositories with the given visibility (public, private, internal)
+	Visibility string
+	// IncludeArchived keeps archived repositories as well
+	IncludeArchived bool
+}
+
+// Match reports whether repo passes all conditions of the filter
+func (f Filter) Match(repo Repository) bool {
+	if repo.Archived && !f.IncludeArchived {
+		return false
+	}
+	if f.Visibility != "" && f.Visibility != repo.Visibility {
+		return false
+	}
+	if f.Name != nil && !f.Name.MatchString(repo.Name) {
+		return false
+	}
+	if len(f.Topics) > 0 && !hasAnyTopic(repo.Topics, f.Topics) {
+		return false
+	}
+	return true
+}
+
+// Apply returns all repositories matching the filter
+func (f Filter) Apply(repos []Repository) []Repository {
+	var filtered []Repository
+	for _, r := range repos {
+		if f.Match(r) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+func hasAnyTopic(topics, wanted []string) bool {
+	for _, w := range wanted {
+		for _, t := range topics {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getJSON requests url and decodes the json response into v, returning the response headers
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		for _, value := range values {
+			req.Header.Add(k, value)
+		}
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code %d requesting %s: %s", resp.StatusCode, url, body)
+	}
+	return resp.Header, json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,107 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Match(t *testing.T) {
+	repo := Repository{Name: "payment-service", Topics: []string{"backend", "go"}, Visibility: "private"}
+	archived := Repository{Name: "old-service", Archived: true, Visibility: "private"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		repo   Repository
+		want   bool
+	}{
+		{"zero value", Filter{}, repo, true},
+		{"archived excluded", Filter{}, archived, false},
+		{"archived included", Filter{IncludeArchived: true}, archived, true},
+		{"topic", Filter{Topics: []string{"frontend", "backend"}}, repo, true},
+		{"missing topic", Filter{Topics: []string{"frontend"}}, repo, false},
+		{"name", Filter{Name: regexp.MustCompile(`-service$`)}, repo, true},
+		{"name mismatch", Filter{Name: regexp.MustCompile(`^web-`)}, repo, false},
+		{"visibility", Filter{Visibility: "private"}, repo, true},
+		{"visibility mismatch", Filter{Visibility: "public"}, repo, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(tt.repo))
+		})
+	}
+	assert.Equal(t, []Repository{repo}, Filter{}.Apply([]Repository{repo, archived}))
+}
+
+func TestRepository_CloneURL(t *testing.T) {
+	r := Repository{SSHURL: "git@github.com:acme/a.git", HTTPURL: "https://github.com/acme/a.git"}
+	assert.Equal(t, "git@github.com:acme/a.git", r.CloneURL(false))
+	assert.Equal(t, "https://github.com/acme/a.git", r.CloneURL(true))
+}
+
+func TestGitHub_OrgRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orgs/acme/repos", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var repos []githubRepository
+		count := perPage
+		if page == 2 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("repo-%d-%d", page, i)
+			repos = append(repos, githubRepository{Name: name, FullName: "acme/" + name, SSHURL: "git@github.com:acme/" + name + ".git", Private: true})
+		}
+		_ = json.NewEncoder(w).Encode(repos)
+	}))
+	defer server.Close()
+
+	repos, err := GitHub{BaseURL: server.URL, Token: "secret"}.OrgRepositories(context.Background(), "acme")
+
+	assert.NoError(t, err)
+	assert.Len(t, repos, perPage+1)
+	assert.Equal(t, "repo-2-0", repos[perPage].Name)
+	assert.Equal(t, "git@github.com:acme/repo-2-0.git", repos[perPage].SSHURL)
+	assert.Equal(t, "private", repos[perPage].Visibility)
+}
+
+func TestGitHub_OrgRepositories_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := GitHub{BaseURL: server.URL}.OrgRepositories(context.Background(), "acme")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGitLab_GroupRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/groups/platform/backend/projects", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		assert.Equal(t, "true", r.URL.Query().Get("include_subgroups"))
+		w.Header().Set("X-Next-Page", "")
+		_ = json.NewEncoder(w).Encode([]gitlabProject{
+			{Name: "api", PathWithNamespace: "platform/backend/api", SSHURLToRepo: "git@gitlab.com:platform/backend/api.git", Topics: []string{"go"}, Visibility: "internal"},
+			{Name: "legacy", TagList: []string{"java"}, Archived: true},
+		})
+	}))
+	defer server.Close()
+
+	repos, err := GitLab{BaseURL: server.URL + "/", Token: "secret"}.GroupRepositories(context.Background(), "platform/backend")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Repository{
+		{Name: "api", FullName: "platform/backend/api", SSHURL: "git@gitlab.com:platform/backend/api.git", Topics: []string{"go"}, Visibility: "internal"},
+		{Name: "legacy", Topics: []string{"java"}, Archived: true},
+	}, repos)
+}
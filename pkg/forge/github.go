@@ -0,0 +1,74 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitHubURL is the API base url of github.com, GitHub Enterprise uses https://<host>/api/v3
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub lists repositories of a GitHub organization
+type GitHub struct {
+	// BaseURL of the API, defaults to DefaultGitHubURL
+	BaseURL string
+	// Token is sent as bearer token, required for private repositories
+	Token  string
+	Client *http.Client
+}
+
+type githubRepository struct {
+	Name       string   `json:"name"`
+	FullName   string   `json:"full_name"`
+	SSHURL     string   `json:"ssh_url"`
+	CloneURL   string   `json:"clone_url"`
+	Topics     []string `json:"topics"`
+	Visibility string   `json:"visibility"`
+	Private    bool     `json:"private"`
+	Archived   bool     `json:"archived"`
+}
+
+// OrgRepositories returns all repositories of the organization org
+func (g GitHub) OrgRepositories(ctx context.Context, org string) ([]Repository, error) {
+	base := strings.TrimSuffix(g.BaseURL, "/")
+	if base == "" {
+		base = DefaultGitHubURL
+	}
+	header := http.Header{"Accept": {"application/vnd.github+json"}}
+	if g.Token != "" {
+		header.Set("Authorization", "Bearer "+g.Token)
+	}
+
+	var repos []Repository
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s/orgs/%s/repos?type=all&per_page=%d&page=%d", base, url.PathEscape(org), perPage, page)
+		var result []githubRepository
+		if _, err := getJSON(ctx, g.Client, u, header, &result); err != nil {
+			return nil, err
+		}
+		for _, r := range result {
+			visibility := r.Visibility
+			if visibility == "" {
+				visibility = "public"
+				if r.Private {
+					visibility = "private"
+				}
+			}
+			repos = append(repos, Repository{
+				Name:       r.Name,
+				FullName:   r.FullName,
+				SSHURL:     r.SSHURL,
+				HTTPURL:    r.CloneURL,
+				Topics:     r.Topics,
+				Visibility: visibility,
+				Archived:   r.Archived,
+			})
+		}
+		if len(result) < perPage {
+			return repos, nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabURL is the API base url of gitlab.com
+const DefaultGitLabURL = "https://gitlab.com/api/v4"
+
+// GitLab lists projects of a GitLab group including its subgroups
+type GitLab struct {
+	// BaseURL of the API, defaults to DefaultGitLabURL
+	BaseURL string
+	// Token is sent as private token, required for private and internal projects
+	Token  string
+	Client *http.Client
+}
+
+type gitlabProject struct {
+	Name              string   `json:"name"`
+	PathWithNamespace string   `json:"path_with_namespace"`
+	SSHURLToRepo      string   `json:"ssh_url_to_repo"`
+	HTTPURLToRepo     string   `json:"http_url_to_repo"`
+	Topics            []string `json:"topics"`
+	TagList           []string `json:"tag_list"`
+	Visibility        string   `json:"visibility"`
+	Archived          bool     `json:"archived"`
+}
+
+// GroupRepositories returns all projects of group and its subgroups, group is the id or the full path, e.g. platform/backend
+func (g GitLab) GroupRepositories(ctx context.Context, group string) ([]Repository, error) {
+	base := strings.TrimSuffix(g.BaseURL, "/")
+	if base == "" {
+		base = DefaultGitLabURL
+	}
+	header := http.Header{}
+	if g.Token != "" {
+		header.Set("PRIVATE-TOKEN", g.Token)
+	}
+
+	var repos []Repository
+	for page := 1; ; page++ {
+		u := fmt.Sprintf("%s/groups/%s/projects?include_subgroups=true&per_page=%d&page=%d", base, url.PathEscape(group), perPage, page)
+		var result []gitlabProject
+		respHeader, err := getJSON(ctx, g.Client, u, header, &result)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result {
+			topics := p.Topics
+			if len(topics) == 0 {
+				// tag_list is the deprecated name of topics in older GitLab versions
+				topics = p.TagList
+			}
+			repos = append(repos, Repository{
+				Name:       p.Name,
+				FullName:   p.PathWithNamespace,
+				SSHURL:     p.SSHURLToRepo,
+				HTTPURL:    p.HTTPURLToRepo,
+				Topics:     topics,
+				Visibility: p.Visibility,
+				Archived:   p.Archived,
+			})
+		}
+		// X-Next-Page is empty on the last page, it is omitted for very large results
+		if _, ok := respHeader["X-Next-Page"]; ok && respHeader.Get("X-Next-Page") == "" || len(result) < perPage {
+			return repos, nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package freeze evaluates release freeze windows
+package freeze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Window is a period in which releases are not allowed. All set constraints must match, a window without constraints is always active.
+type Window struct {
+	Name string
+	// From and To limit the window to a range of dates, both days are included, the zero time means open-ended
+	From, To time.Time
+	// Weekdays limit the window to some days of the week, empty means every day
+	Weekdays []time.Weekday
+	// Start and End limit the window to a time of day, measured from midnight. End before Start spans midnight,
+	// both zero means the whole day.
+	Start, End time.Duration
+	// Location all constraints are evaluated in, nil means UTC
+	Location *time.Location
+}
+
+// Active reports whether t is within the window
+func (w Window) Active(t time.Time) bool {
+	t = t.In(w.location())
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.location())
+	if !w.From.IsZero() && day.Before(w.From) {
+		return false
+	}
+	if !w.To.IsZero() && day.After(w.To) {
+		return false
+	}
+	if len(w.Weekdays) > 0 && !containsWeekday(w.Weekdays, t.Weekday()) {
+		return false
+	}
+	if w.Start == 0 && w.End == 0 {
+		return true
+	}
+	// the wall clock time, on days with a daylight saving time change it differs from the time since midnight
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start <= w.End {
+		return tod >= w.Start && tod < w.End
+	}
+	return tod >= w.Start || tod < w.End
+}
+
+func (w Window) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+func containsWeekday(days []time.Weekday, d time.Weekday) bool {
+	for _, day := range days {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the window, e.g. "holidays (2026-12-20 - 2027-01-06, Europe/Berlin)"
+func (w Window) String() string {
+	var parts []string
+	switch {
+	case !w.From.IsZero() && !w.To.IsZero():
+		parts = append(parts, w.From.Format(dateLayout)+" - "+w.To.Format(dateLayout))
+	case !w.From.IsZero():
+		parts = append(parts, "from "+w.From.Format(dateLayout))
+	case !w.To.IsZero():
+		parts = append(parts, "until "+w.To.Format(dateLayout))
+	}
+	if len(w.Weekdays) > 0 {
+		days := make([]string, len(w.Weekdays))
+		for i, d := range w.Weekdays {
+			days[i] = d.String()[:3]
+		}
+		parts = append(parts, strings.Join(days, ","))
+	}
+	if w.Start != 0 || w.End != 0 {
+		parts = append(parts, formatTimeOfDay(w.Start)+"-"+formatTimeOfDay(w.End))
+	}
+	if len(parts) == 0 {
+		return w.Name
+	}
+	return fmt.Sprintf("%s (%s, %s)", w.Name, strings.Join(parts, ", "), w.location())
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// ParseDate parses a date like 2026-12-24 in loc
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	d, err := time.ParseInLocation(dateLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	return d, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekday parses a day of the week like fri or Friday
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(s)
+	if len(name) >= 3 {
+		if d, ok := weekdays[name[:3]]; ok && strings.HasPrefix(strings.ToLower(d.String()), name) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// ParseTimeRange parses a time of day range like 15:00-24:00 or 22:00-06:00
+func ParseTimeRange(s string) (start, end time.Duration, err error) {
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", s)
+	}
+	if start, err = parseTimeOfDay(bounds[0]); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimeOfDay(bounds[1]); err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("invalid time range %q, start and end are equal", s)
+	}
+	return start, end, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hm := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if len(hm) != 2 {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	h, errH := strconv.Atoi(hm[0])
+	m, errM := strconv.Atoi(hm[1])
+	if errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// ActiveWindow returns the first window of windows which is active at t
+func ActiveWindow(windows []Window, t time.Time) (Window, bool) {
+	for _, w := range windows {
+		if w.Active(t) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
@@ -0,0 +1,94 @@
+package freeze
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow_Active(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	from, _ := ParseDate("2026-12-20", berlin)
+	to, _ := ParseDate("2027-01-06", berlin)
+	holidays := Window{Name: "holidays", From: from, To: to, Location: berlin}
+	fridayAfternoon := Window{Name: "friday", Weekdays: []time.Weekday{time.Friday}, Start: 15 * time.Hour, End: 24 * time.Hour}
+	nights := Window{Name: "nights", Start: 22 * time.Hour, End: 6 * time.Hour}
+
+	tests := []struct {
+		name   string
+		window Window
+		t      time.Time
+		want   bool
+	}{
+		{"before the range", holidays, time.Date(2026, 12, 19, 22, 59, 0, 0, time.UTC), false},
+		{"first day in the time zone of the window", holidays, time.Date(2026, 12, 19, 23, 0, 0, 0, time.UTC), true},
+		{"last day", holidays, time.Date(2027, 1, 6, 22, 59, 0, 0, time.UTC), true},
+		{"after the range", holidays, time.Date(2027, 1, 6, 23, 0, 0, 0, time.UTC), false},
+		{"friday morning", fridayAfternoon, time.Date(2026, 10, 16, 14, 59, 0, 0, time.UTC), false},
+		{"friday afternoon", fridayAfternoon, time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC), true},
+		{"friday midnight", fridayAfternoon, time.Date(2026, 10, 16, 23, 59, 59, 0, time.UTC), true},
+		{"saturday", fridayAfternoon, time.Date(2026, 10, 17, 16, 0, 0, 0, time.UTC), false},
+		{"late evening", nights, time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), true},
+		{"early morning", nights, time.Date(2026, 10, 16, 5, 59, 0, 0, time.UTC), true},
+		{"noon", nights, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), false},
+		{"always", Window{Name: "always"}, time.Now(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.window.Active(tt.t))
+		})
+	}
+}
+
+func TestWindow_String(t *testing.T) {
+	from, _ := ParseDate("2026-12-20", nil)
+	to, _ := ParseDate("2027-01-06", nil)
+
+	assert.Equal(t, "holidays (2026-12-20 - 2027-01-06, UTC)", Window{Name: "holidays", From: from, To: to}.String())
+	assert.Equal(t, "friday (Fri, 15:00-24:00, UTC)", Window{Name: "friday", Weekdays: []time.Weekday{time.Friday}, Start: 15 * time.Hour, End: 24 * time.Hour}.String())
+	assert.Equal(t, "always", Window{Name: "always"}.String())
+}
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"fri", "Friday", "FRI"} {
+		d, err := ParseWeekday(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, time.Friday, d)
+	}
+	for _, s := range []string{"", "fr", "fry", "funday"} {
+		_, err := ParseWeekday(s)
+		assert.Error(t, err, s)
+	}
+
+	start, end, err := ParseTimeRange("22:00-06:30")
+	require.NoError(t, err)
+	assert.Equal(t, 22*time.Hour, start)
+	assert.Equal(t, 6*time.Hour+30*time.Minute, end)
+	for _, s := range []string{"22:00", "25:00-06:00", "10:00-10:00", "10:60-11:00", "24:01-10:00", "ten-eleven"} {
+		_, _, err := ParseTimeRange(s)
+		assert.Error(t, err, s)
+	}
+
+	_, err = ParseDate("24.12.2026", nil)
+	assert.Error(t, err)
+}
+
+func TestActiveWindow(t *testing.T) {
+	windows := []Window{
+		{Name: "weekend", Weekdays: []time.Weekday{time.Saturday, time.Sunday}},
+		{Name: "always"},
+	}
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	w, ok := ActiveWindow(windows, saturday)
+	assert.True(t, ok)
+	assert.Equal(t, "weekend", w.Name)
+	w, ok = ActiveWindow(windows, saturday.AddDate(0, 0, 2))
+	assert.True(t, ok)
+	assert.Equal(t, "always", w.Name)
+	_, ok = ActiveWindow(windows[:1], saturday.AddDate(0, 0, 2))
+	assert.False(t, ok)
+}
@@ -0,0 +1,344 @@
+// Package gittest provides hermetic git remotes for tests. Repositories are bare repositories in a temporary
+// directory, seeded with commits, branches and tags by a small DSL and served via file:// or an in-process smart
+// HTTP server:
+//
+//	r := gittest.NewRepo(t,
+//		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"),
+//		gittest.Commit("feature"), gittest.Branch("main"),
+//	)
+//	res, err := releaser.Release(ctx, r.URL(), releaser.Options{SourceBranch: "main", CreateTag: true, NextVersion: repo.MINOR})
+//	assert.Equal(t, r.Hash("main"), r.Hash("v1.1.0"))
+package gittest
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// epoch is the time of the first commit, every further commit is one minute later, which keeps hashes reproducible
+var epoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Repo is a bare repository seeded by Steps
+type Repo struct {
+	t      testing.TB
+	path   string
+	name   string
+	server *Server
+	// head is the commit the next Commit, Branch or Tag step applies to
+	head    plumbing.Hash
+	commits int
+}
+
+// Step seeds a Repo, see Commit, Branch, Tag, AnnotatedTag and Checkout
+type Step func(*Repo) error
+
+// NewRepo creates a bare repository in a temporary directory and applies steps, it is reachable via Repo.URL
+func NewRepo(t testing.TB, steps ...Step) *Repo {
+	t.Helper()
+	return newRepo(t, filepath.Join(t.TempDir(), "repo.git"), "repo", nil, steps)
+}
+
+func newRepo(t testing.TB, path, name string, server *Server, steps []Step) *Repo {
+	t.Helper()
+	if _, err := git.PlainInit(path, true); err != nil {
+		t.Fatalf("gittest: could not create repository %s: %v", path, err)
+	}
+	r := &Repo{t: t, path: path, name: name, server: server}
+	r.Apply(steps...)
+	return r
+}
+
+// Apply applies further steps, e.g. to simulate new commits between two releases
+func (r *Repo) Apply(steps ...Step) {
+	r.t.Helper()
+	for _, step := range steps {
+		if err := step(r); err != nil {
+			r.t.Fatalf("gittest: %s: %v", r.name, err)
+		}
+	}
+}
+
+// Path is the directory of the bare repository
+func (r *Repo) Path() string {
+	return r.path
+}
+
+// URL is the file:// URL of the repository, it requires the git binary like every file:// remote of go-git
+func (r *Repo) URL() string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(r.path)}).String()
+}
+
+// HTTPURL is the smart HTTP URL of the repository, it is empty if the repository wasn't created by Server.NewRepo
+func (r *Repo) HTTPURL() string {
+	if r.server == nil {
+		return ""
+	}
+	return r.server.URL(r.name)
+}
+
+// Head returns the hash of the commit the next step applies to
+func (r *Repo) Head() string {
+	if r.head.IsZero() {
+		return ""
+	}
+	return r.head.String()
+}
+
+// Hash returns the hash name points to, name is a branch, a tag or a full reference name.
+// Annotated tags are returned as they are advertised by the remote, i.e. the hash of the tag object.
+// It returns an empty string if the reference doesn't exist.
+func (r *Repo) Hash(name string) string {
+	r.t.Helper()
+	ref, err := r.reference(r.open(), name)
+	if err != nil {
+		return ""
+	}
+	return ref.Hash().String()
+}
+
+// File returns the content of the file path in the commit of the branch or tag name,
+// it returns an empty string if the reference or the file doesn't exist
+func (r *Repo) File(name, path string) string {
+	r.t.Helper()
+	repo := r.open()
+	ref, err := r.reference(repo, name)
+	if err != nil {
+		return ""
+	}
+	hash := ref.Hash()
+	if tag, err := repo.TagObject(hash); err == nil {
+		hash = tag.Target
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return ""
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return ""
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return ""
+	}
+	return content
+}
+
+// Refs returns all branches and tags by full reference name, e.g. refs/heads/main
+func (r *Repo) Refs() map[string]string {
+	r.t.Helper()
+	refs := map[string]string{}
+	iter, err := r.open().References()
+	if err != nil {
+		r.t.Fatalf("gittest: %s: %v", r.name, err)
+	}
+	_ = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference && (ref.Name().IsBranch() || ref.Name().IsTag()) {
+			refs[ref.Name().String()] = ref.Hash().String()
+		}
+		return nil
+	})
+	return refs
+}
+
+// Branches returns the sorted short names of all branches
+func (r *Repo) Branches() []string {
+	return r.shortNames(plumbing.ReferenceName.IsBranch)
+}
+
+// Tags returns the sorted short names of all tags
+func (r *Repo) Tags() []string {
+	return r.shortNames(plumbing.ReferenceName.IsTag)
+}
+
+func (r *Repo) shortNames(filter func(plumbing.ReferenceName) bool) []string {
+	var names []string
+	for name := range r.Refs() {
+		if filter(plumbing.ReferenceName(name)) {
+			names = append(names, plumbing.ReferenceName(name).Short())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// open opens the repository for every access, so changes pushed by other processes are always visible
+func (r *Repo) open() *git.Repository {
+	r.t.Helper()
+	repo, err := git.PlainOpen(r.path)
+	if err != nil {
+		r.t.Fatalf("gittest: could not open repository %s: %v", r.path, err)
+	}
+	return repo
+}
+
+func (r *Repo) reference(repo *git.Repository, name string) (*plumbing.Reference, error) {
+	for _, refName := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(name),
+		plumbing.NewBranchReferenceName(name),
+		plumbing.NewTagReferenceName(name),
+	} {
+		ref, err := repo.Reference(refName, true)
+		if err == nil {
+			return ref, nil
+		}
+	}
+	return nil, fmt.Errorf("reference %s not found", name)
+}
+
+func (r *Repo) signature() object.Signature {
+	return object.Signature{Name: "gittest", Email: "gittest@example.com", When: epoch.Add(time.Duration(r.commits) * time.Minute)}
+}
+
+func (r *Repo) setReference(ref *plumbing.Reference) error {
+	return r.open().Storer.SetReference(ref)
+}
+
+// Commit creates a commit on top of the current commit, the first commit has no parent.
+// The commit contains a single file with msg as content.
+func Commit(msg string) Step {
+	return commit(msg, "CHANGES", msg+"\n", false)
+}
+
+// CommitFile creates a commit on top of the current commit, which keeps the files of the current commit
+// and adds or replaces the top-level file name with content
+func CommitFile(msg, name, content string) Step {
+	return commit(msg, name, content, true)
+}
+
+func commit(msg, name, content string, keep bool) Step {
+	return func(r *Repo) error {
+		repo := r.open()
+		st := repo.Storer
+		blob := st.NewEncodedObject()
+		blob.SetType(plumbing.BlobObject)
+		w, err := blob.Writer()
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		blobHash, err := st.SetEncodedObject(blob)
+		if err != nil {
+			return err
+		}
+
+		var entries []object.TreeEntry
+		if keep && !r.head.IsZero() {
+			parent, err := repo.CommitObject(r.head)
+			if err != nil {
+				return err
+			}
+			tree, err := parent.Tree()
+			if err != nil {
+				return err
+			}
+			for _, e := range tree.Entries {
+				if e.Name != name {
+					entries = append(entries, e)
+				}
+			}
+		}
+		entries = append(entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: blobHash})
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		treeHash, err := encode(st, &object.Tree{Entries: entries})
+		if err != nil {
+			return err
+		}
+
+		commit := object.Commit{Author: r.signature(), Committer: r.signature(), Message: msg, TreeHash: treeHash}
+		if !r.head.IsZero() {
+			commit.ParentHashes = []plumbing.Hash{r.head}
+		}
+		hash, err := encode(st, &commit)
+		if err != nil {
+			return err
+		}
+		r.head = hash
+		r.commits++
+		return nil
+	}
+}
+
+// Branch creates or moves the branch name to the current commit, the first branch becomes the default branch (HEAD)
+func Branch(name string) Step {
+	return func(r *Repo) error {
+		if r.head.IsZero() {
+			return fmt.Errorf("branch %s: no commit yet", name)
+		}
+		if len(r.Branches()) == 0 {
+			if err := r.setReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(name))); err != nil {
+				return err
+			}
+		}
+		return r.setReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), r.head))
+	}
+}
+
+// Tag creates the lightweight tag name on the current commit
+func Tag(name string) Step {
+	return func(r *Repo) error {
+		if r.head.IsZero() {
+			return fmt.Errorf("tag %s: no commit yet", name)
+		}
+		return r.setReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), r.head))
+	}
+}
+
+// AnnotatedTag creates the annotated tag name with msg on the current commit
+func AnnotatedTag(name, msg string) Step {
+	return func(r *Repo) error {
+		if r.head.IsZero() {
+			return fmt.Errorf("tag %s: no commit yet", name)
+		}
+		tag := object.Tag{Name: name, Tagger: r.signature(), Message: msg, TargetType: plumbing.CommitObject, Target: r.head}
+		hash, err := encode(r.open().Storer, &tag)
+		if err != nil {
+			return err
+		}
+		return r.setReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash))
+	}
+}
+
+// Checkout makes the commit of the branch or tag name the current commit, e.g. to commit on another branch
+func Checkout(name string) Step {
+	return func(r *Repo) error {
+		repo := r.open()
+		ref, err := r.reference(repo, name)
+		if err != nil {
+			return err
+		}
+		hash := ref.Hash()
+		if tag, err := repo.TagObject(hash); err == nil {
+			hash = tag.Target
+		}
+		r.head = hash
+		return nil
+	}
+}
+
+type encoder interface {
+	Encode(plumbing.EncodedObject) error
+}
+
+func encode(st storer.EncodedObjectStorer, o encoder) (plumbing.Hash, error) {
+	obj := st.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return st.SetEncodedObject(obj)
+}
@@ -0,0 +1,106 @@
+package gittest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRepo(t *testing.T) {
+	r := NewRepo(t,
+		Commit("initial"), Branch("main"), Tag("v1.0.0"),
+		Commit("feature"), Branch("main"), AnnotatedTag("v1.1.0", "release v1.1.0"),
+		Checkout("v1.0.0"), Commit("fix"), Branch("release/v1.0.1"),
+	)
+
+	assert.Equal(t, []string{"main", "release/v1.0.1"}, r.Branches())
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, r.Tags())
+	assert.Equal(t, r.Head(), r.Hash("release/v1.0.1"))
+	assert.NotEqual(t, r.Hash("main"), r.Hash("v1.1.0"), "annotated tags point to the tag object")
+	assert.Equal(t, r.Hash("main"), r.Hash("refs/heads/main"))
+	assert.Empty(t, r.Hash("i-do-not-exist"))
+	assert.Len(t, r.Refs(), 4)
+	assert.Empty(t, r.HTTPURL())
+
+	head, err := r.open().Head()
+	require.NoError(t, err)
+	assert.Equal(t, plumbing.NewBranchReferenceName("main"), head.Name())
+}
+
+func TestCommitFile(t *testing.T) {
+	r := NewRepo(t,
+		Commit("initial"), Branch("main"),
+		CommitFile("add readme", "README.md", "# readme\n"), CommitFile("update changes", "CHANGES", "changed\n"), Branch("main"),
+	)
+
+	assert.Equal(t, "# readme\n", r.File("main", "README.md"))
+	assert.Equal(t, "changed\n", r.File("main", "CHANGES"))
+	assert.Empty(t, r.File("main", "i-do-not-exist"))
+	assert.Empty(t, r.File("i-do-not-exist", "CHANGES"))
+}
+
+func TestNewRepo_reproducible(t *testing.T) {
+	steps := []Step{Commit("initial"), Branch("main")}
+	assert.Equal(t, NewRepo(t, steps...).Hash("main"), NewRepo(t, steps...).Hash("main"))
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(t)
+	r := s.NewRepo("app", Commit("initial"), Branch("main"), Tag("v1.0.0"), Commit("feature"), Branch("main"))
+	assert.Equal(t, s.URL("app"), r.HTTPURL())
+
+	for name, url := range map[string]string{"http": r.HTTPURL(), "file": r.URL()} {
+		t.Run(name, func(t *testing.T) {
+			rem := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{url}})
+			refs, err := rem.ListContext(context.Background(), &git.ListOptions{})
+			require.NoError(t, err)
+			names := map[string]string{}
+			for _, ref := range refs {
+				names[ref.Name().String()] = ref.Hash().String()
+			}
+			assert.Equal(t, r.Hash("main"), names["refs/heads/main"])
+			assert.Equal(t, r.Hash("v1.0.0"), names["refs/tags/v1.0.0"])
+		})
+	}
+}
+
+func TestServer_push_and_clone(t *testing.T) {
+	s := NewServer(t)
+	r := s.NewRepo("app", Commit("initial"), Branch("main"))
+
+	clone, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{URL: r.HTTPURL()})
+	require.NoError(t, err)
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, r.Hash("main"), head.Hash().String())
+
+	err = clone.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName("v0.1.0"), head.Hash()))
+	require.NoError(t, err)
+	err = clone.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/tags/v0.1.0:refs/tags/v0.1.0"}})
+	require.NoError(t, err)
+	assert.Equal(t, r.Hash("main"), r.Hash("v0.1.0"))
+}
+
+func TestServer_errors(t *testing.T) {
+	s := NewServer(t)
+	s.NewRepo("app", Commit("initial"), Branch("main"))
+
+	rem := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{s.URL("i-do-not-exist")}})
+	_, err := rem.List(&git.ListOptions{})
+	assert.ErrorIs(t, err, transport.ErrRepositoryNotFound)
+
+	s.RequireBasicAuth("bot", "secret")
+	rem = git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{s.URL("app")}})
+	_, err = rem.List(&git.ListOptions{})
+	assert.ErrorIs(t, err, transport.ErrAuthenticationRequired)
+	_, err = rem.List(&git.ListOptions{Auth: &http.BasicAuth{Username: "bot", Password: "secret"}})
+	assert.NoError(t, err)
+}
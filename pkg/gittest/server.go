@@ -0,0 +1,212 @@
+package gittest
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+// Server serves the repositories created by Server.NewRepo via the git smart HTTP protocol, it runs in-process
+// and doesn't need the git binary
+type Server struct {
+	t      testing.TB
+	dir    string
+	http   *httptest.Server
+	git    transport.Transport
+	mu     sync.Mutex
+	user   string
+	passwd string
+}
+
+// NewServer starts a smart HTTP server, it is closed at the end of the test
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{t: t, dir: t.TempDir()}
+	s.git = server.NewServer(loader(s.dir))
+	s.http = httptest.NewServer(s)
+	t.Cleanup(s.http.Close)
+	return s
+}
+
+// NewRepo creates the bare repository name and applies steps, it is reachable via Repo.HTTPURL and Repo.URL
+func (s *Server) NewRepo(name string, steps ...Step) *Repo {
+	s.t.Helper()
+	return newRepo(s.t, filepath.Join(s.dir, name+".git"), name, s, steps)
+}
+
+// URL returns the URL of the repository name, it doesn't need to exist
+func (s *Server) URL(name string) string {
+	return s.http.URL + "/" + name + ".git"
+}
+
+// RequireBasicAuth rejects all requests without the given credentials
+func (s *Server) RequireBasicAuth(username, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user, s.passwd = username, password
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == "" {
+		return true
+	}
+	user, passwd, ok := r.BasicAuth()
+	return ok && user == s.user && passwd == s.passwd
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="gittest"`)
+		http.Error(w, "authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	p := path.Clean(r.URL.Path)
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(p, "/info/refs"):
+		s.advertise(w, r, strings.TrimSuffix(p, "/info/refs"), r.URL.Query().Get("service"))
+	case r.Method == http.MethodPost && strings.HasSuffix(p, "/"+transport.UploadPackServiceName):
+		s.uploadPack(w, r, strings.TrimSuffix(p, "/"+transport.UploadPackServiceName))
+	case r.Method == http.MethodPost && strings.HasSuffix(p, "/"+transport.ReceivePackServiceName):
+		s.receivePack(w, r, strings.TrimSuffix(p, "/"+transport.ReceivePackServiceName))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) advertise(w http.ResponseWriter, r *http.Request, repoPath, service string) {
+	ep := &transport.Endpoint{Protocol: "file", Path: repoPath}
+	var sess transport.Session
+	var err error
+	switch service {
+	case transport.UploadPackServiceName:
+		sess, err = s.git.NewUploadPackSession(ep, nil)
+	case transport.ReceivePackServiceName:
+		sess, err = s.git.NewReceivePackSession(ep, nil)
+	default:
+		http.Error(w, "only smart HTTP is supported", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer sess.Close()
+
+	ar, err := sess.AdvertisedReferencesContext(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ar.Prefix = [][]byte{[]byte("# service=" + service), pktline.Flush}
+	w.Header().Set("Content-Type", "application/x-"+service+"-advertisement")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = ar.Encode(w)
+}
+
+func (s *Server) uploadPack(w http.ResponseWriter, r *http.Request, repoPath string) {
+	body, err := requestBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	sess, err := s.git.NewUploadPackSession(&transport.Endpoint{Protocol: "file", Path: repoPath}, nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer sess.Close()
+
+	req := packp.NewUploadPackRequest()
+	if err := req.Decode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := sess.UploadPack(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer res.Close()
+	w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+	_ = res.Encode(w)
+}
+
+func (s *Server) receivePack(w http.ResponseWriter, r *http.Request, repoPath string) {
+	body, err := requestBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer body.Close()
+
+	sess, err := s.git.NewReceivePackSession(&transport.Endpoint{Protocol: "file", Path: repoPath}, nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer sess.Close()
+
+	req := packp.NewReferenceUpdateRequest()
+	if err := req.Decode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// a failed update is reported to the client within the report status
+	status, err := sess.ReceivePack(r.Context(), req)
+	if status == nil {
+		if err != nil {
+			writeError(w, err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-git-receive-pack-result")
+	_ = status.Encode(w)
+}
+
+func requestBody(r *http.Request) (io.ReadCloser, error) {
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		return gzip.NewReader(r.Body)
+	}
+	return r.Body, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, transport.ErrRepositoryNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// loader opens the bare repositories below its directory for every request
+type loader string
+
+func (l loader) Load(ep *transport.Endpoint) (storer.Storer, error) {
+	repo, err := git.PlainOpen(filepath.Join(string(l), filepath.FromSlash(path.Clean("/"+ep.Path))))
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, transport.ErrRepositoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo.Storer, nil
+}
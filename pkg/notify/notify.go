@@ -0,0 +1,144 @@
+// Package notify announces the releases of a run in chats or to webhooks
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+)
+
+// Status of a single release
+const (
+	StatusCreated  = "created"
+	StatusUpToDate = "up_to_date"
+	StatusPlanned  = "planned"
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped"
+	StatusYanked   = "yanked"
+	StatusPruned   = "pruned"
+)
+
+// DefaultTemplate lists the created and failed releases, it renders nothing if there are none
+const DefaultTemplate = `{{- if or (.Count "created") (.Count "failed") -}}
+git-releaser: {{.Count "created"}} released, {{.Count "failed"}} failed
+{{- range .Releases}}
+{{- if eq .Status "created"}}
+- {{.RepoURL}} {{.NextVersion}}{{with .PreviousVersion}} (previous {{.}}){{end}}{{with .TagMessage}}: {{.}}{{end}}
+{{- else if eq .Status "failed"}}
+- {{.RepoURL}} failed: {{.Error}}
+{{- end}}
+{{- end}}
+{{- end}}`
+
+// Release is the outcome of the release of a single repo.
+// For the yank and prune commands only the RepoURL of Result is set, their outcome is Yank or Prune.
+type Release struct {
+	releaser.Result
+	Yank   *releaser.YankResult  `json:"yank,omitempty"`
+	Prune  *releaser.PruneResult `json:"prune,omitempty"`
+	Status string                `json:"status"`
+	Error  string                `json:"error,omitempty"`
+}
+
+// Summary contains all releases of a run, it is rendered by the message template
+type Summary struct {
+	Releases []Release `json:"releases"`
+}
+
+// Count returns the number of releases with status
+func (s Summary) Count(status string) int {
+	var n int
+	for _, r := range s.Releases {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Notifier sends msg, the rendered summary, to a chat or webhook
+type Notifier interface {
+	// Name identifies the notifier in errors, it must not contain secrets like the webhook url
+	Name() string
+	Notify(ctx context.Context, msg string, summary Summary) error
+}
+
+// ParseTemplate parses a message template, the template is executed with a Summary
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("notification").Option("missingkey=error").Parse(text)
+}
+
+// Render executes tmpl with summary, leading and trailing white space is removed
+func Render(tmpl *template.Template, summary Summary) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, summary); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Send renders summary and sends it to all notifiers, nothing is sent if the message is empty.
+// All notifiers are tried, the returned error joins the errors of all failed notifiers.
+func Send(ctx context.Context, tmpl *template.Template, summary Summary, notifiers ...Notifier) error {
+	if len(notifiers) == 0 {
+		return nil
+	}
+	msg, err := Render(tmpl, summary)
+	if err != nil {
+		return err
+	}
+	if msg == "" {
+		return nil
+	}
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, msg, summary); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postJSON posts body to endpoint, every status except 2xx is an error
+func postJSON(ctx context.Context, client *http.Client, endpoint string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("invalid url")
+	}
+	for k, values := range header {
+		for _, value := range values {
+			req.Header.Add(k, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the url of chat webhooks is a secret, don't log it
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/releaser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var summary = Summary{Releases: []Release{
+	{Result: releaser.Result{RepoURL: "git@github.com:acme/api.git", PreviousVersion: "v1.2.0", NextVersion: "v1.3.0", Created: true}, Status: StatusCreated},
+	{Result: releaser.Result{RepoURL: "git@github.com:acme/web.git", NextVersion: "v0.1.0", UpToDate: true}, Status: StatusUpToDate},
+	{Result: releaser.Result{RepoURL: "git@github.com:acme/worker.git"}, Status: StatusFailed, Error: "could not get source branch"},
+}}
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func recorder(t *testing.T, status int) (*httptest.Server, *[]request) {
+	var requests []request
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{header: r.Header, body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s, &requests
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	require.NoError(t, err)
+
+	msg, err := Render(tmpl, summary)
+	assert.NoError(t, err)
+	assert.Equal(t, `git-releaser: 1 released, 1 failed
+- git@github.com:acme/api.git v1.3.0 (previous v1.2.0)
+- git@github.com:acme/worker.git failed: could not get source branch`, msg)
+
+	msg, err = Render(tmpl, Summary{Releases: summary.Releases[1:2]})
+	assert.NoError(t, err)
+	assert.Empty(t, msg)
+
+	msg, err = Render(tmpl, Summary{Releases: []Release{{Result: releaser.Result{RepoURL: "git@github.com:acme/api.git", NextVersion: "v1.3.1", TagMessage: "Release freeze holidays overridden: security fix"}, Status: StatusCreated}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `git-releaser: 1 released, 0 failed
+- git@github.com:acme/api.git v1.3.1: Release freeze holidays overridden: security fix`, msg)
+
+	tmpl, err = ParseTemplate(`{{range .Releases}}{{.RepoURL}}={{.Status}} {{end}}`)
+	require.NoError(t, err)
+	msg, err = Render(tmpl, summary)
+	assert.NoError(t, err)
+	assert.Equal(t, "git@github.com:acme/api.git=created git@github.com:acme/web.git=up_to_date git@github.com:acme/worker.git=failed", msg)
+
+	_, err = ParseTemplate("{{.Releases")
+	assert.Error(t, err)
+}
+
+func TestSend(t *testing.T) {
+	slackServer, slackRequests := recorder(t, http.StatusOK)
+	teamsServer, teamsRequests := recorder(t, http.StatusOK)
+	webhookServer, webhookRequests := recorder(t, http.StatusNoContent)
+	tmpl, _ := ParseTemplate("")
+
+	err := Send(context.Background(), tmpl, summary,
+		Slack{WebhookURL: slackServer.URL},
+		Teams{WebhookURL: teamsServer.URL},
+		Webhook{URL: webhookServer.URL, Secret: "s3cret"},
+	)
+	require.NoError(t, err)
+
+	require.Len(t, *slackRequests, 1)
+	var slack map[string]string
+	require.NoError(t, json.Unmarshal((*slackRequests)[0].body, &slack))
+	assert.Contains(t, slack["text"], "1 released, 1 failed")
+
+	require.Len(t, *teamsRequests, 1)
+	var teams teamsMessageCard
+	require.NoError(t, json.Unmarshal((*teamsRequests)[0].body, &teams))
+	assert.Equal(t, "MessageCard", teams.Type)
+	assert.Equal(t, "git-releaser: 1 released, 1 failed", teams.Summary)
+	assert.Contains(t, teams.Text, "failed\n\n- git@github.com:acme/api.git")
+
+	require.Len(t, *webhookRequests, 1)
+	webhook := (*webhookRequests)[0]
+	assert.Equal(t, Sign("s3cret", webhook.body), webhook.header.Get(SignatureHeader))
+	var payload webhookPayload
+	require.NoError(t, json.Unmarshal(webhook.body, &payload))
+	assert.Equal(t, summary, payload.Summary)
+	assert.Contains(t, payload.Text, "1 released")
+}
+
+func TestSend_nothing_to_announce(t *testing.T) {
+	server, requests := recorder(t, http.StatusOK)
+	tmpl, _ := ParseTemplate("")
+
+	err := Send(context.Background(), tmpl, Summary{Releases: summary.Releases[1:2]}, Slack{WebhookURL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Empty(t, *requests)
+}
+
+func TestSend_errors(t *testing.T) {
+	failing, _ := recorder(t, http.StatusForbidden)
+	working, requests := recorder(t, http.StatusOK)
+	tmpl, _ := ParseTemplate("")
+
+	err := Send(context.Background(), tmpl, summary,
+		Slack{WebhookURL: failing.URL},
+		Teams{WebhookURL: "http://127.0.0.1:0/secret-token"},
+		Webhook{URL: working.URL},
+	)
+
+	assert.ErrorContains(t, err, "slack: unexpected status code 403")
+	assert.ErrorContains(t, err, "teams: ")
+	assert.NotContains(t, err.Error(), "secret-token")
+	assert.Len(t, *requests, 1, "a failing notifier must not stop the others")
+	assert.Empty(t, (*requests)[0].header.Get(SignatureHeader))
+}
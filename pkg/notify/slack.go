@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Slack posts to a Slack incoming webhook
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+func (s Slack) Name() string {
+	return "slack"
+}
+
+func (s Slack) Notify(ctx context.Context, msg string, _ Summary) error {
+	body, err := json.Marshal(map[string]string{"text": msg})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, body)
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Teams posts a message card to a Microsoft Teams incoming webhook
+type Teams struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+type teamsMessageCard struct {
+	Type    string `json:"@type"`
+	Context string `json:"@context"`
+	Summary string `json:"summary"`
+	Text    string `json:"text"`
+}
+
+func (t Teams) Name() string {
+	return "teams"
+}
+
+func (t Teams) Notify(ctx context.Context, msg string, _ Summary) error {
+	body, err := json.Marshal(teamsMessageCard{
+		Type:    "MessageCard",
+		Context: "https://schema.org/extensions",
+		Summary: strings.SplitN(msg, "\n", 2)[0],
+		// Teams renders markdown, single line breaks would be dropped
+		Text: strings.ReplaceAll(msg, "\n", "\n\n"),
+	})
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.Client, t.WebhookURL, nil, body)
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// SignatureHeader holds the HMAC-SHA256 signature of the body sent by Webhook, e.g. sha256=4a5b...
+const SignatureHeader = "X-Git-Releaser-Signature"
+
+// Webhook posts the message and the whole summary as JSON
+type Webhook struct {
+	URL string
+	// Secret signs the body using HMAC-SHA256, the signature is sent in the SignatureHeader, empty disables signing
+	Secret string
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Text string `json:"text"`
+	Summary
+}
+
+func (w Webhook) Name() string {
+	return "webhook"
+}
+
+func (w Webhook) Notify(ctx context.Context, msg string, summary Summary) error {
+	body, err := json.Marshal(webhookPayload{Text: msg, Summary: summary})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if w.Secret != "" {
+		header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+	return postJSON(ctx, w.Client, w.URL, header, body)
+}
+
+// Sign returns the signature of body as sent in the SignatureHeader, receivers compare it using hmac.Equal
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
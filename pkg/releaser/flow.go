@@ -0,0 +1,148 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// FlowOptions configure Start and Finish, which create and complete git-flow releases
+type FlowOptions struct {
+	// Main is the production branch, which receives the finished release and its tag, typically main
+	Main string
+	// Develop is the integration branch, the release is merged back into it, typically develop
+	Develop string
+	// DryRun performs the merges in memory, so conflicts are detected, but pushes nothing
+	DryRun bool
+}
+
+// ErrNoReleaseBranch is returned by Finish if there is no release branch to finish
+var ErrNoReleaseBranch = errors.New("no release branch found")
+
+// ErrFlowGranularity is returned by Start and Finish for release branches per line, git-flow release branches belong to a single version
+var ErrFlowGranularity = errors.New("git-flow requires a release branch per version")
+
+// Start starts a git-flow release of repoURL: the release branch of the next version is created from FlowOptions.Develop, see Release.
+// The latest release is determined from the release branches and tags, but no tag is created, it is created by Finish.
+// Options.SourceBranch, CreateBranch and CreateTag don't apply.
+func Start(ctx context.Context, repoURL string, opts Options, flow FlowOptions) (Result, error) {
+	if opts.Naming.Granularity != remote.PerPatch {
+		return Result{RepoURL: repoURL}, ErrFlowGranularity
+	}
+	opts.SourceBranch, opts.CreateBranch, opts.CreateTag, opts.versionTags = flow.Develop, true, false, true
+	if flow.DryRun {
+		return Plan(ctx, repoURL, opts)
+	}
+	return Release(ctx, repoURL, opts)
+}
+
+// Finish finishes a git-flow release of repoURL: the latest release branch below Options.TargetBranch, or the one of Options.Version,
+// is merged into FlowOptions.Main, the merge commit is tagged and merged back into FlowOptions.Develop.
+// The merges are performed in an in-memory clone, a conflict returns a remote.MergeConflictError and nothing is pushed.
+// All changed branches and the tag are pushed atomically. A release which is already tagged is up to date.
+// Options.Remote has to implement remote.Cloner, the release branch is kept.
+func Finish(ctx context.Context, repoURL string, opts Options, flow FlowOptions) (Result, error) {
+	res := Result{RepoURL: repoURL}
+	if opts.Naming.Granularity != remote.PerPatch {
+		return res, ErrFlowGranularity
+	}
+	scheme := opts.Scheme
+	if scheme == nil {
+		scheme = version.SemVer{}
+	}
+	repoOpts := []repo.Option{repo.WithAuth(opts.Auth), repo.WithLogger(opts.logger()), repo.WithRetry(opts.Retry), repo.WithNaming(opts.Naming), repo.WithScheme(scheme)}
+	if opts.Remote != nil {
+		repoOpts = append(repoOpts, repo.WithRemote(opts.Remote))
+	}
+	r, err := repo.New(ctx, repoURL, repoOpts...)
+	if err != nil {
+		return res, err
+	}
+
+	branch, next, err := releaseBranch(r, opts, scheme)
+	if err != nil {
+		return res, err
+	}
+	res.SourceBranch, res.SourceHash, res.NextVersion = branch.Name().Short(), branch.Hash().String(), next
+	if res.Tag, err = opts.Naming.TagName(opts.TargetBranch, next); err != nil {
+		return res, err
+	}
+	res.TagMessage = opts.TagMessage
+	// the previous version of a finished release is its own tag, like for an up to date release
+	for _, tag := range r.GetVersionTags() {
+		if v, _ := opts.Naming.Granularity.RefVersion(tag, scheme); scheme.Compare(v, next) <= 0 {
+			setPrevious(&res, tag, opts.Naming, scheme)
+		}
+	}
+	res.UpToDate = r.TagExists(res.Tag)
+	if res.UpToDate {
+		return res, nil
+	}
+	if opts.Policy != nil {
+		if err := opts.Policy(ctx, res); err != nil {
+			return res, err
+		}
+	}
+
+	ws, err := r.Clone(ctx)
+	if err != nil {
+		return res, err
+	}
+	mainHead, err := ws.Merge(branch.Hash(), flow.Main, fmt.Sprintf("Merge branch '%s' into %s", res.SourceBranch, flow.Main))
+	if err != nil {
+		return res, err
+	}
+	if err := ws.Tag(res.Tag, mainHead, opts.TagMessage); err != nil {
+		return res, err
+	}
+	if _, err := ws.Merge(mainHead, flow.Develop, fmt.Sprintf("Merge tag '%s' into %s", res.Tag, flow.Develop)); err != nil {
+		return res, err
+	}
+	res.Merged = []string{flow.Main, flow.Develop}
+	if flow.DryRun {
+		return res, nil
+	}
+
+	if opts.PreRelease != nil {
+		if err := opts.PreRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPreReleaseFailed, err)
+		}
+	}
+	if err := ws.Push(ctx); err != nil {
+		return res, err
+	}
+	res.Created = true
+	if opts.PostRelease != nil {
+		if err := opts.PostRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPostReleaseFailed, err)
+		}
+	}
+	return res, nil
+}
+
+// releaseBranch returns the release branch of Options.Version or the latest release branch and its version
+func releaseBranch(r *repo.Repo, opts Options, scheme version.Scheme) (*plumbing.Reference, string, error) {
+	var want string
+	if opts.Version != "" {
+		var err error
+		if want, err = scheme.Parse(opts.Version); err != nil {
+			return nil, "", err
+		}
+	}
+	branches := r.GetVersionBranches(opts.TargetBranch)
+	for i := len(branches) - 1; i >= 0; i-- {
+		v, _ := opts.Naming.Granularity.RefVersion(branches[i], scheme)
+		if want == "" || scheme.Compare(v, want) == 0 {
+			return branches[i], v, nil
+		}
+	}
+	if want != "" {
+		return nil, "", fmt.Errorf("%w for %s", ErrNoReleaseBranch, want)
+	}
+	return nil, "", ErrNoReleaseBranch
+}
@@ -0,0 +1,186 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"),
+		gittest.Commit("feature"), gittest.Branch("develop"),
+	)
+	ctx := context.Background()
+	flow := FlowOptions{Main: "main", Develop: "develop", DryRun: true}
+	opts := Options{SourceBranch: "main", TargetBranch: "release", CreateTag: true, NextVersion: repo.MINOR}
+
+	res, err := Start(ctx, r.URL(), opts, flow)
+	require.NoError(t, err)
+	assert.Equal(t, "develop", res.SourceBranch)
+	assert.Equal(t, "v1.0.0", res.PreviousVersion, "the tags of finished releases are taken into account")
+	assert.Equal(t, "release/v1.1.0", res.Branch)
+	assert.Empty(t, res.Tag)
+	assert.Equal(t, []string{"develop", "main"}, r.Branches())
+
+	flow.DryRun = false
+	res, err = Start(ctx, r.URL(), opts, flow)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, r.Hash("develop"), r.Hash("release/v1.1.0"))
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+
+	res, err = Start(ctx, r.URL(), opts, flow)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+
+	_, err = Start(ctx, r.URL(), Options{Naming: remote.Naming{Granularity: remote.PerMajor}}, flow)
+	assert.ErrorIs(t, err, ErrFlowGranularity)
+}
+
+func TestFinish(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0"),
+		gittest.CommitFile("feature", "FEATURE", "feature\n"), gittest.Branch("release/v1.1.0"),
+		gittest.CommitFile("next feature", "NEXT", "next\n"), gittest.Branch("develop"),
+	)
+	ctx := context.Background()
+	flow := FlowOptions{Main: "main", Develop: "develop"}
+	var calls []string
+	opts := Options{
+		TargetBranch: "release",
+		TagMessage:   "release v1.1.0",
+		PostRelease: func(_ context.Context, res Result) error {
+			calls = append(calls, "post "+res.Tag)
+			return nil
+		},
+	}
+
+	dry := flow
+	dry.DryRun = true
+	res, err := Finish(ctx, r.URL(), opts, dry)
+	require.NoError(t, err)
+	assert.False(t, res.Created)
+	assert.Equal(t, []string{"main", "develop"}, res.Merged)
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+
+	res, err = Finish(ctx, r.URL(), opts, flow)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, "release/v1.1.0", res.SourceBranch)
+	assert.Equal(t, r.Hash("release/v1.1.0"), res.SourceHash)
+	assert.Equal(t, "v1.0.0", res.PreviousVersion)
+	assert.Equal(t, "v1.1.0", res.NextVersion)
+	assert.Equal(t, "v1.1.0", res.Tag)
+	assert.Equal(t, []string{"post v1.1.0"}, calls)
+
+	repository, err := git.PlainOpen(r.Path())
+	require.NoError(t, err)
+	tag, err := repository.TagObject(plumbing.NewHash(r.Hash("v1.1.0")))
+	require.NoError(t, err)
+	assert.Equal(t, r.Hash("main"), tag.Target.String(), "the merge commit on main is tagged")
+	develop, err := repository.CommitObject(plumbing.NewHash(r.Hash("develop")))
+	require.NoError(t, err)
+	assert.Equal(t, tag.Target, develop.ParentHashes[1], "main is merged back into develop")
+	assert.Equal(t, "feature\n", r.File("main", "FEATURE"))
+	assert.Empty(t, r.File("main", "NEXT"))
+	assert.Equal(t, "next\n", r.File("develop", "NEXT"))
+
+	// a tagged release is finished
+	res, err = Finish(ctx, r.URL(), opts, flow)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "v1.1.0", res.PreviousVersion)
+	assert.Len(t, calls, 1)
+}
+
+func TestFinish_conflict(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("develop"),
+		gittest.Commit("release"), gittest.Branch("release/v1.0.0"),
+		gittest.Checkout("main"), gittest.Commit("hotfix"), gittest.Branch("main"),
+	)
+	main := r.Hash("main")
+	opts := Options{PreRelease: func(context.Context, Result) error {
+		t.Error("the pre-release hook must not run for a conflicting release")
+		return nil
+	}}
+
+	res, err := Finish(context.Background(), r.URL(), opts, FlowOptions{Main: "main", Develop: "develop"})
+
+	var conflict *remote.MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{"CHANGES"}, conflict.Paths)
+	assert.False(t, res.Created)
+	assert.Empty(t, r.Tags())
+	assert.Equal(t, main, r.Hash("main"))
+}
+
+func TestFinish_errors(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("develop"), gittest.Branch("release/v1.0.0"),
+		gittest.Commit("fix"), gittest.Branch("release/v1.0.1"),
+	)
+	ctx := context.Background()
+	flow := FlowOptions{Main: "main", Develop: "develop"}
+
+	_, err := Finish(ctx, r.URL(), Options{Version: "v2.0.0"}, flow)
+	assert.ErrorIs(t, err, ErrNoReleaseBranch)
+	assert.EqualError(t, err, "no release branch found for v2.0.0")
+
+	_, err = Finish(ctx, r.URL(), Options{Naming: remote.Naming{Granularity: remote.PerMinor}}, flow)
+	assert.ErrorIs(t, err, ErrFlowGranularity)
+
+	errRefused := errors.New("refused")
+	res, err := Finish(ctx, r.URL(), Options{Version: "1.0.0", Policy: func(context.Context, Result) error { return errRefused }}, flow)
+	assert.ErrorIs(t, err, errRefused)
+	assert.Equal(t, "release/v1.0.0", res.SourceBranch)
+	assert.Empty(t, r.Tags())
+
+	_, err = Finish(ctx, r.URL(), Options{}, FlowOptions{Main: "production", Develop: "develop"})
+	assert.EqualError(t, err, "branch production not found")
+
+	empty := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"))
+	_, err = Finish(ctx, empty.URL(), Options{}, flow)
+	assert.ErrorIs(t, err, ErrNoReleaseBranch)
+}
+
+// cloningRemote counts the clones of the git remote
+type cloningRemote struct {
+	*remote.GitRepo
+	clones int
+}
+
+func (c *cloningRemote) Clone(ctx context.Context, repoURL string) (*remote.Workspace, error) {
+	c.clones++
+	return c.GitRepo.Clone(ctx, repoURL)
+}
+
+func TestFinish_remote(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("develop"),
+		gittest.CommitFile("feature", "FEATURE", "feature\n"), gittest.Branch("release/v1.0.0"),
+	)
+	ctx := context.Background()
+	flow := FlowOptions{Main: "main", Develop: "develop"}
+
+	fake := &fakeRemote{refs: []*plumbing.Reference{plumbing.NewHashReference("refs/heads/release/v1.0.0", plumbing.NewHash(r.Hash("release/v1.0.0")))}}
+	_, err := Finish(ctx, r.URL(), Options{Remote: fake}, flow)
+	assert.ErrorIs(t, err, remote.ErrNotSupported)
+	assert.Empty(t, r.Tags())
+
+	cloning := &cloningRemote{GitRepo: &remote.GitRepo{}}
+	res, err := Finish(ctx, r.URL(), Options{Remote: cloning}, flow)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, 1, cloning.clones)
+	assert.Equal(t, []string{"v1.0.0"}, r.Tags())
+}
@@ -0,0 +1,205 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// PromoteOptions configure Promote
+type PromoteOptions struct {
+	// Candidate is the version of the release candidate like v2.0.0-rc.3
+	Candidate string
+	// CarryMessage annotates the final tag with the message of the candidate tag
+	CarryMessage bool
+	// CarryNotes adds the messages of all candidates of the version up to Candidate to the message of the final tag, oldest first
+	CarryNotes bool
+	// DryRun only determines the final release without pushing anything
+	DryRun bool
+}
+
+// ErrPromoteScheme is returned by Promote for other versioning schemes than semantic versioning
+var ErrPromoteScheme = errors.New("promotion requires semantic versioning")
+
+// ErrNotCandidate is returned by Promote if the candidate isn't a pre-release like v2.0.0-rc.3
+var ErrNotCandidate = errors.New("not a release candidate")
+
+// ErrCandidateNotFound is returned by Promote if there is no tag of the candidate
+var ErrCandidateNotFound = errors.New("release candidate not found")
+
+// ErrFinalExists is returned by Promote if the final version or a newer final version is released already
+var ErrFinalExists = errors.New("final release exists already")
+
+// Promote releases the commit of the release candidate tag like v2.0.0-rc.3 of repoURL as final version v2.0.0. The final tag,
+// and the release branch if Options.CreateBranch is set, point to the same commit as the candidate tag. The promotion is refused
+// if the final version or a newer final version exists. A final tag of the candidate commit is up to date.
+// Options.SourceBranch, CreateTag, NextVersion, Version and Line don't apply.
+func Promote(ctx context.Context, repoURL string, opts Options, promote PromoteOptions) (Result, error) {
+	res := Result{RepoURL: repoURL}
+	if _, semver := opts.Scheme.(version.SemVer); opts.Scheme != nil && !semver {
+		return res, ErrPromoteScheme
+	}
+	candidate, err := version.Parse(promote.Candidate)
+	if err != nil {
+		return res, err
+	}
+	if !candidate.IsPreRelease() {
+		return res, fmt.Errorf("%w: %s", ErrNotCandidate, candidate)
+	}
+	final := version.Version{Major: candidate.Major, Minor: candidate.Minor, Patch: candidate.Patch}
+	res.NextVersion = final.String()
+
+	repoOpts := []repo.Option{repo.WithAuth(opts.Auth), repo.WithLogger(opts.logger()), repo.WithRetry(opts.Retry), repo.WithNaming(opts.Naming), repo.WithScheme(version.SemVer{})}
+	if opts.Remote != nil {
+		repoOpts = append(repoOpts, repo.WithRemote(opts.Remote))
+	}
+	r, err := repo.New(ctx, repoURL, repoOpts...)
+	if err != nil {
+		return res, err
+	}
+	branches := r.GetVersionBranches(opts.TargetBranch)
+	tags := r.GetVersionTags()
+
+	// the candidates of the version up to the promoted one, oldest first
+	var candidateTag *plumbing.Reference
+	var candidates []*plumbing.Reference
+	for _, tag := range tags {
+		v, ok := refVersion(tag, opts.Naming)
+		if !ok || !v.IsPreRelease() || (version.Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}) != final {
+			continue
+		}
+		if c := version.Compare(v, candidate); c <= 0 {
+			candidates = append(candidates, tag)
+			if c == 0 {
+				candidateTag = tag
+			}
+		}
+	}
+	if candidateTag == nil {
+		return res, fmt.Errorf("%w: %s", ErrCandidateNotFound, candidate)
+	}
+	res.SourceBranch, res.SourceHash = candidateTag.Name().Short(), candidateTag.Hash().String()
+
+	var target string
+	if opts.CreateBranch {
+		target = opts.TargetBranch
+		if res.Branch, err = opts.Naming.BranchName(target, res.NextVersion); err != nil {
+			return res, err
+		}
+	}
+	if res.Tag, err = opts.Naming.TagName(target, res.NextVersion); err != nil {
+		return res, err
+	}
+	if err := checkFinals(&res, final, append(branches, tags...), opts.Naming); err != nil {
+		return res, err
+	}
+	if res.UpToDate {
+		return res, nil
+	}
+
+	res.TagMessage = opts.TagMessage
+	if promote.CarryMessage || promote.CarryNotes {
+		messages, err := r.TagMessages(ctx, candidates...)
+		if err != nil {
+			return res, err
+		}
+		res.TagMessage = promotionMessage(opts.TagMessage, candidateTag, candidates, messages, promote)
+	}
+	if opts.Policy != nil {
+		if err := opts.Policy(ctx, res); err != nil {
+			return res, err
+		}
+	}
+	if promote.DryRun {
+		return res, nil
+	}
+
+	if opts.PreRelease != nil {
+		if err := opts.PreRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPreReleaseFailed, err)
+		}
+	}
+	if err := r.PromoteCandidate(ctx, candidateTag, res.NextVersion, res.Tag, opts.CreateBranch, res.TagMessage); err != nil {
+		return res, err
+	}
+	res.Created = true
+	if opts.PostRelease != nil {
+		if err := opts.PostRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPostReleaseFailed, err)
+		}
+	}
+	return res, nil
+}
+
+// checkFinals sets the previous final release of res and refuses the promotion if final or a newer final version exists.
+// The final tag and release branch of res pointing to the candidate commit are an earlier promotion, which is up to date
+// even if newer final versions have been released since.
+func checkFinals(res *Result, final version.Version, refs []*plumbing.Reference, naming remote.Naming) error {
+	promoted := map[plumbing.ReferenceName]bool{plumbing.NewTagReferenceName(res.Tag): true}
+	if res.Branch != "" {
+		promoted[plumbing.NewBranchReferenceName(res.Branch)] = true
+	}
+	var exists error
+	for _, ref := range refs {
+		v, ok := refVersion(ref, naming)
+		if !ok || v.IsPreRelease() {
+			continue
+		}
+		switch c := version.Compare(v, final); {
+		case c < 0:
+			// the tags are sorted by version, so the last one is the latest
+			if ref.Name().IsTag() {
+				res.PreviousVersion, res.PreviousRelease = ref.Name().Short(), v.String()
+			}
+		case c == 0 && promoted[ref.Name()] && ref.Hash().String() == res.SourceHash:
+			if ref.Name().IsTag() {
+				res.UpToDate, res.PreviousVersion, res.PreviousRelease = true, ref.Name().Short(), v.String()
+			}
+		case exists == nil:
+			exists = fmt.Errorf("%w: %s", ErrFinalExists, ref.Name().Short())
+		}
+	}
+	if res.UpToDate {
+		return nil
+	}
+	return exists
+}
+
+// promotionMessage combines the message of the final tag from message and the messages of the candidate tags
+func promotionMessage(message string, candidate *plumbing.Reference, candidates []*plumbing.Reference, messages map[plumbing.ReferenceName]string, promote PromoteOptions) string {
+	var parts []string
+	if message != "" {
+		parts = append(parts, strings.TrimSpace(message))
+	}
+	if m := strings.TrimSpace(messages[candidate.Name()]); promote.CarryMessage && m != "" {
+		parts = append(parts, m)
+	}
+	if promote.CarryNotes {
+		var notes []string
+		for _, c := range candidates {
+			if m := strings.TrimSpace(messages[c.Name()]); m != "" {
+				notes = append(notes, fmt.Sprintf("%s:\n%s", c.Name().Short(), m))
+			}
+		}
+		if len(notes) > 0 {
+			parts = append(parts, "Release notes:\n\n"+strings.Join(notes, "\n\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// refVersion returns the semantic version of a release branch or tag
+func refVersion(ref *plumbing.Reference, naming remote.Naming) (version.Version, bool) {
+	s, ok := naming.Granularity.RefVersion(ref, version.SemVer{})
+	if !ok {
+		return version.Version{}, false
+	}
+	v, err := version.Parse(s)
+	return v, err == nil
+}
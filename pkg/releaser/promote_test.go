@@ -0,0 +1,134 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromote(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.4.0"),
+		gittest.Commit("rewrite"), gittest.AnnotatedTag("v2.0.0-rc.1", "New API"),
+		gittest.Commit("fix"), gittest.Tag("v2.0.0-rc.2"),
+		gittest.Commit("docs"), gittest.AnnotatedTag("v2.0.0-rc.3", "Migration guide\n"),
+		gittest.Commit("next"), gittest.AnnotatedTag("v2.0.0-rc.4", "not promoted"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	var calls []string
+	opts := Options{
+		TargetBranch: "release",
+		CreateBranch: true,
+		PostRelease: func(_ context.Context, res Result) error {
+			calls = append(calls, "post "+res.Tag)
+			return nil
+		},
+	}
+	promote := PromoteOptions{Candidate: "2.0.0-rc.3", CarryMessage: true, CarryNotes: true, DryRun: true}
+
+	res, err := Promote(ctx, r.URL(), opts, promote)
+	require.NoError(t, err)
+	assert.False(t, res.Created)
+	assert.Equal(t, "v2.0.0-rc.3", res.SourceBranch)
+	assert.Equal(t, "v1.4.0", res.PreviousVersion)
+	assert.Equal(t, "v2.0.0", res.NextVersion)
+	assert.Equal(t, "release/v2.0.0", res.Branch)
+	assert.Equal(t, "v2.0.0", res.Tag)
+	assert.Equal(t, "Migration guide\n\nRelease notes:\n\nv2.0.0-rc.1:\nNew API\n\nv2.0.0-rc.3:\nMigration guide", res.TagMessage)
+	assert.Empty(t, r.Hash("v2.0.0"))
+
+	promote.DryRun = false
+	res, err = Promote(ctx, r.URL(), opts, promote)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, []string{"post v2.0.0"}, calls)
+	repository, err := git.PlainOpen(r.Path())
+	require.NoError(t, err)
+	tag, err := repository.TagObject(plumbing.NewHash(r.Hash("v2.0.0")))
+	require.NoError(t, err, "v2.0.0 is an annotated tag")
+	assert.Equal(t, res.TagMessage+"\n", tag.Message)
+	candidate, err := repository.TagObject(plumbing.NewHash(r.Hash("v2.0.0-rc.3")))
+	require.NoError(t, err)
+	assert.Equal(t, candidate.Target, tag.Target, "the final tag points to the commit of the candidate")
+	assert.Equal(t, candidate.Target.String(), r.Hash("release/v2.0.0"))
+
+	// promoting the same candidate again is up to date, other candidates of the version are refused
+	res, err = Promote(ctx, r.URL(), opts, promote)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "v2.0.0", res.PreviousVersion)
+	assert.Len(t, calls, 1)
+
+	_, err = Promote(ctx, r.URL(), opts, PromoteOptions{Candidate: "v2.0.0-rc.4"})
+	assert.ErrorIs(t, err, ErrFinalExists)
+	assert.EqualError(t, err, "final release exists already: release/v2.0.0")
+}
+
+func TestPromote_lightweight_tag(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.0.0-rc.1"))
+
+	res, err := Promote(context.Background(), r.URL(), Options{}, PromoteOptions{Candidate: "v1.0.0-rc.1", CarryMessage: true})
+
+	require.NoError(t, err)
+	assert.Empty(t, res.TagMessage, "the final tag of a lightweight candidate is lightweight")
+	assert.Empty(t, res.Branch)
+	assert.Equal(t, r.Hash("main"), r.Hash("v1.0.0"))
+	assert.Equal(t, []string{"main"}, r.Branches())
+}
+
+func TestPromote_errors(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("c3")),
+		plumbing.NewHashReference("refs/tags/v1.0.0-rc.1", plumbing.NewHash("a1")),
+		plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("a1")),
+		plumbing.NewHashReference("refs/tags/v2.0.0-rc.1", plumbing.NewHash("b1")),
+		plumbing.NewHashReference("refs/tags/v2.1.0", plumbing.NewHash("c1")),
+		plumbing.NewHashReference("refs/tags/v3.0.0-rc.1", plumbing.NewHash("c2")),
+	}}
+	ctx := context.Background()
+	opts := Options{Remote: fake}
+	calver, err := version.NewCalVer("YYYY.MINOR", nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		candidate string
+		wantErr   error
+	}{
+		{"calver", Options{Remote: fake, Scheme: calver}, "v1.0.0-rc.1", ErrPromoteScheme},
+		{"invalid", opts, "latest", version.ErrInvalid},
+		{"final version", opts, "v1.0.0", ErrNotCandidate},
+		{"missing candidate", opts, "v1.0.0-rc.2", ErrCandidateNotFound},
+		{"newer final", opts, "v2.0.0-rc.1", ErrFinalExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Promote(ctx, "https://github.com/acme/api.git", tt.opts, PromoteOptions{Candidate: tt.candidate})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	res, err := Promote(ctx, "https://github.com/acme/api.git", opts, PromoteOptions{Candidate: "v1.0.0-rc.1"})
+	assert.NoError(t, err)
+	assert.True(t, res.UpToDate)
+
+	errRefused := errors.New("refused")
+	opts.Policy = func(context.Context, Result) error { return errRefused }
+	_, err = Promote(ctx, "https://github.com/acme/api.git", opts, PromoteOptions{Candidate: "v3.0.0-rc.1"})
+	assert.ErrorIs(t, err, errRefused)
+	assert.Empty(t, fake.created)
+
+	opts.Policy = nil
+	res, err = Promote(ctx, "https://github.com/acme/api.git", opts, PromoteOptions{Candidate: "v3.0.0-rc.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.1.0", res.PreviousVersion)
+	assert.Equal(t, []string{"tag v3.0.0"}, fake.created)
+}
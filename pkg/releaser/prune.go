@@ -0,0 +1,133 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// PruneOptions configure the rules of Prune, a zero value disables a rule. The keep rules protect the release branches they keep,
+// the other release branches are deleted, or with OlderThan only once they are older.
+type PruneOptions struct {
+	// KeepPatches keeps the latest release branches of every minor version, e.g. release/v1.4.1 and release/v1.4.2 for 2
+	KeepPatches int
+	// KeepMinors keeps the release branches of the latest minor versions, e.g. of v1.4 and v2.0 for 2
+	KeepMinors int
+	// OlderThan deletes release branches whose commit is older, if their version is tagged as well and they aren't kept by KeepPatches or KeepMinors
+	OlderThan time.Duration
+	// DryRun only determines the outdated release branches without deleting them
+	DryRun bool
+}
+
+// PruneResult describes a planned or performed pruning of release branches
+type PruneResult struct {
+	RepoURL string `json:"repo_url"`
+	// Latest is the latest release, which is never deleted, empty if there is none
+	Latest string `json:"latest"`
+	// Deleted are the outdated release branches, oldest first
+	Deleted []string `json:"deleted"`
+	// UpToDate is set if there are no outdated release branches
+	UpToDate bool `json:"up_to_date"`
+	// Pruned is set once the outdated release branches have been deleted
+	Pruned bool `json:"pruned"`
+}
+
+// ErrPruneScheme is returned by Prune for other versioning schemes than semantic versioning
+var ErrPruneScheme = errors.New("pruning requires semantic versioning")
+
+// ErrNoPruneRule is returned by Prune if none of the rules of PruneOptions is set
+var ErrNoPruneRule = errors.New("no prune rule set")
+
+// Prune deletes the outdated release branches below Options.TargetBranch of repoURL by the rules of PruneOptions with a single push.
+// The latest release is never deleted, release branches of a line like release/v1.4 aren't patch branches and are kept.
+// Without outdated release branches the repo is up to date. Only Options.TargetBranch, Scheme, Naming, Auth, Retry, Logger and Remote apply.
+func Prune(ctx context.Context, repoURL string, opts Options, prune PruneOptions) (PruneResult, error) {
+	res := PruneResult{RepoURL: repoURL}
+	if _, semver := opts.Scheme.(version.SemVer); opts.Scheme != nil && !semver {
+		return res, ErrPruneScheme
+	}
+	if prune.KeepPatches <= 0 && prune.KeepMinors <= 0 && prune.OlderThan <= 0 {
+		return res, ErrNoPruneRule
+	}
+	repoOpts := []repo.Option{repo.WithAuth(opts.Auth), repo.WithLogger(opts.logger()), repo.WithRetry(opts.Retry), repo.WithNaming(opts.Naming), repo.WithScheme(version.SemVer{})}
+	if opts.Remote != nil {
+		repoOpts = append(repoOpts, repo.WithRemote(opts.Remote))
+	}
+	r, err := repo.New(ctx, repoURL, repoOpts...)
+	if err != nil {
+		return res, err
+	}
+	// the branches are sorted by version, oldest first
+	branches := r.GetVersionBranches(opts.TargetBranch)
+	tagged := map[version.Version]bool{}
+	for _, tag := range r.GetVersionTags() {
+		if v, ok := refVersion(tag, opts.Naming); ok {
+			v.Build = ""
+			tagged[v] = true
+		}
+	}
+	latest := r.GetLatestVersionReference()
+	if latest != nil {
+		res.Latest = latest.Name().Short()
+	}
+
+	keepRules := prune.KeepPatches > 0 || prune.KeepMinors > 0
+	outdated := map[plumbing.ReferenceName]bool{}
+	patches := map[version.Version]int{}
+	// the commit dates of the branches of the OlderThan rule are fetched at once afterwards
+	var aging []*plumbing.Reference
+	for i := len(branches) - 1; i >= 0; i-- {
+		branch := branches[i]
+		if _, ok := version.FromRef(branch.Name().Short()); !ok {
+			continue
+		}
+		v, _ := refVersion(branch, opts.Naming)
+		v.Build = ""
+		minor := version.Version{Major: v.Major, Minor: v.Minor}
+		patches[minor]++
+		kept := !(prune.KeepMinors > 0 && len(patches) > prune.KeepMinors) && !(prune.KeepPatches > 0 && patches[minor] > prune.KeepPatches)
+		switch {
+		case latest != nil && branch.Name() == latest.Name():
+			// the latest release is kept
+		case keepRules && kept:
+			// kept branches are protected from the age rule as well
+		case prune.OlderThan > 0:
+			if tagged[v] {
+				aging = append(aging, branch)
+			}
+		default:
+			outdated[branch.Name()] = true
+		}
+	}
+	if len(aging) > 0 {
+		dates, err := r.CommitTimes(ctx, aging...)
+		if err != nil {
+			return res, err
+		}
+		cutoff := opts.now().Add(-prune.OlderThan)
+		for _, branch := range aging {
+			outdated[branch.Name()] = dates[branch.Name()].Before(cutoff)
+		}
+	}
+
+	var names []plumbing.ReferenceName
+	for _, branch := range branches {
+		if outdated[branch.Name()] {
+			names = append(names, branch.Name())
+			res.Deleted = append(res.Deleted, branch.Name().Short())
+		}
+	}
+	res.UpToDate = len(names) == 0
+	if res.UpToDate || prune.DryRun {
+		return res, nil
+	}
+	if err := r.DeleteReferences(ctx, names...); err != nil {
+		return res, err
+	}
+	res.Pruned = true
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package releaser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrune(t *testing.T) {
+	refs := []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("c9")),
+		plumbing.NewHashReference("refs/heads/release/v1.0.0", plumbing.NewHash("c1")),
+		plumbing.NewHashReference("refs/heads/release/v1.0.1", plumbing.NewHash("c2")),
+		plumbing.NewHashReference("refs/heads/release/v1.1.0", plumbing.NewHash("c3")),
+		plumbing.NewHashReference("refs/heads/release/v1.1.1", plumbing.NewHash("c4")),
+		plumbing.NewHashReference("refs/heads/release/v1.1.2", plumbing.NewHash("c5")),
+		plumbing.NewHashReference("refs/heads/release/v2.0.0", plumbing.NewHash("c6")),
+		plumbing.NewHashReference("refs/heads/release/v2", plumbing.NewHash("c6")),
+		plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("c1")),
+		plumbing.NewHashReference("refs/tags/v1.1.2", plumbing.NewHash("c5")),
+		plumbing.NewHashReference("refs/tags/v2.0.0", plumbing.NewHash("c6")),
+	}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-100 * 24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		prune      PruneOptions
+		commitTime time.Time
+		want       []string
+	}{
+		{"keep patches", PruneOptions{KeepPatches: 2}, old, []string{"release/v1.1.0"}},
+		{"keep minors", PruneOptions{KeepMinors: 2}, old, []string{"release/v1.0.0", "release/v1.0.1"}},
+		{"older than", PruneOptions{OlderThan: 90 * 24 * time.Hour}, old, []string{"release/v1.0.0", "release/v1.1.2"}},
+		{"recent", PruneOptions{OlderThan: 90 * 24 * time.Hour}, now.Add(-89 * 24 * time.Hour), nil},
+		{"keep rules", PruneOptions{KeepPatches: 2, KeepMinors: 2}, old, []string{"release/v1.0.0", "release/v1.0.1", "release/v1.1.0"}},
+		// the kept branches are protected from the age rule, the others are only deleted if they are old and tagged
+		{"keep patches protects from older than", PruneOptions{KeepPatches: 3, OlderThan: 90 * 24 * time.Hour}, old, nil},
+		{"all rules", PruneOptions{KeepPatches: 2, KeepMinors: 2, OlderThan: 90 * 24 * time.Hour}, old, []string{"release/v1.0.0"}},
+		{"all rules recent", PruneOptions{KeepPatches: 2, KeepMinors: 2, OlderThan: 90 * 24 * time.Hour}, now.Add(-89 * 24 * time.Hour), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRemote{refs: refs, commitTime: tt.commitTime}
+			res, err := Prune(context.Background(), "https://github.com/acme/api.git", Options{Remote: fake, TargetBranch: "release", Now: func() time.Time { return now }}, tt.prune)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res.Deleted)
+			assert.Equal(t, len(tt.want) == 0, res.UpToDate)
+			assert.Equal(t, len(tt.want) > 0, res.Pruned)
+			assert.Equal(t, "release/v2.0.0", res.Latest, "the latest release is kept")
+			assert.Len(t, fake.deleted, len(tt.want))
+			assert.LessOrEqual(t, fake.commitFetches, 1, "all commit dates are fetched at once")
+		})
+	}
+}
+
+func TestPrune_dry_run(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/release/v1.0.0", plumbing.NewHash("c1")),
+		plumbing.NewHashReference("refs/heads/release/v1.0.1", plumbing.NewHash("c2")),
+	}}
+
+	res, err := Prune(context.Background(), "https://github.com/acme/api.git", Options{Remote: fake}, PruneOptions{KeepPatches: 1, DryRun: true})
+
+	require.NoError(t, err)
+	assert.False(t, res.Pruned)
+	assert.Equal(t, []string{"release/v1.0.0"}, res.Deleted)
+	assert.Empty(t, fake.deleted)
+}
+
+func TestPrune_errors(t *testing.T) {
+	calver, err := version.NewCalVer("YYYY.MINOR", nil)
+	require.NoError(t, err)
+	fake := &fakeRemote{}
+
+	_, err = Prune(context.Background(), "https://github.com/acme/api.git", Options{Remote: fake, Scheme: calver}, PruneOptions{KeepPatches: 1})
+	assert.ErrorIs(t, err, ErrPruneScheme)
+	_, err = Prune(context.Background(), "https://github.com/acme/api.git", Options{Remote: fake}, PruneOptions{})
+	assert.ErrorIs(t, err, ErrNoPruneRule)
+}
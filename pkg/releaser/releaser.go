@@ -0,0 +1,398 @@
+// Package releaser creates new release branches and tags, it is the library behind the git-releaser command line tool.
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/rs/zerolog"
+)
+
+// Options configure the release of a single repository
+type Options struct {
+	// SourceBranch is released, typically main
+	SourceBranch string
+	// TargetBranch is the prefix of the release branches, typically release
+	TargetBranch string
+	CreateBranch bool
+	CreateTag    bool
+	// NextVersion is one of repo.MAJOR, repo.MINOR or repo.PATCH
+	NextVersion int
+	// Version is the explicit version of the new release, it replaces NextVersion.
+	// It must be greater than the latest release and must not exist yet, see repo.Repo.ReleaseVersion.
+	Version string
+	// Scheme detects, orders and increments the versions, nil uses semantic versioning
+	Scheme version.Scheme
+	// Line restricts the release to hotfixes of a release line like v1.3, which only increment the patch number
+	// of the latest release of the line. Without Line, a SourceBranch with a version like release/v1.3.2 selects its line.
+	Line string
+	// Snapshot computes a snapshot version of the source branch like v1.4.0-dev.20261017+g1a2b3c4 instead of a release,
+	// which is based on NextVersion and the commit date and hash of the source branch. CreateBranch and CreateTag are ignored,
+	// a snapshot tag is only pushed if SnapshotTagPrefix is set. Policy doesn't apply to snapshots.
+	Snapshot bool
+	// SnapshotTagPrefix is the namespace of snapshot tags like nightly/, which keeps them apart from the release tags
+	SnapshotTagPrefix string
+	// SnapshotRetention deletes snapshot tags below SnapshotTagPrefix with a commit date older than the retention, 0 keeps all
+	SnapshotRetention time.Duration
+	// Force creates a new release even if the source branch is already released
+	Force bool
+	// Auth is used for all remote operations, nil uses the default ssh agent
+	Auth   transport.AuthMethod
+	Retry  remote.RetryPolicy
+	Naming remote.Naming
+	// TagMessage creates an annotated tag with this message, empty creates a lightweight tag
+	TagMessage string
+	// Remote replaces the git remote, e.g. by a fake in tests, Auth, Retry, Naming and TagMessage don't apply to it
+	Remote remote.CreateBranchAndTager
+	// Logger receives all log output, nil discards it
+	Logger *zerolog.Logger
+	// Now returns the current time for SnapshotRetention and PruneOptions.OlderThan, nil uses time.Now
+	Now func() time.Time
+	// Policy is called after planning by Plan and Release, only if a new release will be created.
+	// An error refuses the release, it is returned unchanged and nothing is pushed.
+	Policy func(context.Context, Result) error
+	// PreRelease is called after Policy, before anything is pushed and only if a new release will be created.
+	// An error aborts the release.
+	PreRelease func(context.Context, Result) error
+	// PostRelease is called after the new release branch and / or tag have been pushed
+	PostRelease func(context.Context, Result) error
+
+	// versionTags determines the latest release from the tags even if CreateTag isn't set, see Start
+	versionTags bool
+}
+
+// Result describes a planned or created release
+type Result struct {
+	RepoURL string `json:"repo_url"`
+	// SourceBranch and SourceHash describe the released commit
+	SourceBranch string `json:"source_branch"`
+	SourceHash   string `json:"source_hash"`
+	// Line is the release line of a hotfix like v1.3, empty for regular releases
+	Line string `json:"line,omitempty"`
+	// PreviousVersion is the name of the latest release branch or tag, empty if there is none
+	PreviousVersion string `json:"previous_version"`
+	// PreviousRelease is the version of PreviousVersion, e.g. v1.4.0 for the line branch release/v1.4
+	PreviousRelease string `json:"previous_release,omitempty"`
+	// NextVersion is the version of the new release
+	NextVersion string `json:"next_version"`
+	// SkippedVersions are the yanked versions skipped to determine NextVersion, oldest first
+	SkippedVersions []string `json:"skipped_versions,omitempty"`
+	// Branch and Tag are the names of the new release branch and tag, empty if not requested
+	Branch string `json:"branch"`
+	Tag    string `json:"tag"`
+	// TagMessage is the message of the annotated tag, empty for lightweight tags
+	TagMessage string `json:"tag_message,omitempty"`
+	// UpToDate is set if the source branch is already released, nothing is created in this case
+	UpToDate bool `json:"up_to_date"`
+	// Created is set once the release branch and / or tag have been pushed
+	Created bool `json:"created"`
+	// Snapshot is set for snapshot versions, see Options.Snapshot
+	Snapshot bool `json:"snapshot,omitempty"`
+	// Pruned are the snapshot tags older than Options.SnapshotRetention, which are deleted by Release
+	Pruned []string `json:"pruned,omitempty"`
+	// Merged are the branches the release branch has been merged into by Finish
+	Merged []string `json:"merged,omitempty"`
+}
+
+// Refs returns the full reference names of the new release branch and tag
+func (r Result) Refs() []string {
+	var refs []string
+	if r.Branch != "" {
+		refs = append(refs, plumbing.NewBranchReferenceName(r.Branch).String())
+	}
+	if r.Tag != "" {
+		refs = append(refs, plumbing.NewTagReferenceName(r.Tag).String())
+	}
+	return refs
+}
+
+// ErrSourceBranchNotFound is returned if Options.SourceBranch doesn't exist in the repository
+var ErrSourceBranchNotFound = errors.New("could not get source branch")
+
+// ErrSnapshotVersion is returned if Options.Snapshot is combined with an explicit Options.Version
+var ErrSnapshotVersion = errors.New("snapshots can't have an explicit version")
+
+// ErrLineBranchTag is returned if a release within an existing line branch like release/v1.4 doesn't create a tag
+var ErrLineBranchTag = errors.New("releases within an existing line branch require a tag")
+
+// ErrPreReleaseFailed is returned if Options.PreRelease failed, nothing has been pushed in this case
+var ErrPreReleaseFailed = errors.New("pre-release hook failed")
+
+// ErrPostReleaseFailed is returned if Options.PostRelease failed, the release has been created nevertheless
+var ErrPostReleaseFailed = errors.New("post-release hook failed")
+
+func (o Options) logger() *zerolog.Logger {
+	if o.Logger == nil {
+		nop := zerolog.Nop()
+		return &nop
+	}
+	return o.Logger
+}
+
+func (o Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+// willCreate reports whether res is a new release, which has to pass the policy and the hooks
+func (o Options) willCreate(res Result) bool {
+	if o.Snapshot {
+		return !res.UpToDate && res.Tag != ""
+	}
+	return !res.UpToDate && (o.CreateBranch || o.CreateTag)
+}
+
+// checkPolicy applies Options.Policy to a new release
+func (o Options) checkPolicy(ctx context.Context, res Result) error {
+	// snapshots aren't releases, so the release policy doesn't apply to them
+	if o.Policy == nil || o.Snapshot || !o.willCreate(res) {
+		return nil
+	}
+	return o.Policy(ctx, res)
+}
+
+// Plan determines the next release of repoURL without pushing anything, a release refused by Options.Policy returns its error
+func Plan(ctx context.Context, repoURL string, opts Options) (Result, error) {
+	_, res, err := plan(ctx, repoURL, opts)
+	if err != nil {
+		return res, err
+	}
+	return res, opts.checkPolicy(ctx, res)
+}
+
+// Release creates the next release branch and / or tag of repoURL, if the source branch isn't released yet or Options.Force is set
+func Release(ctx context.Context, repoURL string, opts Options) (Result, error) {
+	r, res, err := plan(ctx, repoURL, opts)
+	if err != nil {
+		return res, err
+	}
+	if err := opts.checkPolicy(ctx, res); err != nil {
+		return res, err
+	}
+	willCreate := opts.willCreate(res)
+	if willCreate && opts.PreRelease != nil {
+		if err := opts.PreRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPreReleaseFailed, err)
+		}
+	}
+	if opts.Snapshot {
+		err = releaseSnapshot(ctx, r, res, willCreate)
+	} else {
+		// releases within an existing line branch only create a tag
+		err = r.CreateNewRelease(ctx, res.Branch != "", opts.CreateTag, opts.Force)
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Created = willCreate
+	if res.Created && opts.PostRelease != nil {
+		if err := opts.PostRelease(ctx, res); err != nil {
+			return res, fmt.Errorf("%w: %w", ErrPostReleaseFailed, err)
+		}
+	}
+	return res, nil
+}
+
+func plan(ctx context.Context, repoURL string, opts Options) (*repo.Repo, Result, error) {
+	res := Result{RepoURL: repoURL, SourceBranch: opts.SourceBranch}
+
+	scheme := opts.Scheme
+	if scheme == nil {
+		scheme = version.SemVer{}
+	}
+	repoOpts := []repo.Option{repo.WithAuth(opts.Auth), repo.WithLogger(opts.logger()), repo.WithRetry(opts.Retry), repo.WithNaming(opts.Naming), repo.WithTagMessage(opts.TagMessage), repo.WithScheme(scheme)}
+	if opts.Remote != nil {
+		repoOpts = append(repoOpts, repo.WithRemote(opts.Remote))
+	}
+	r, err := repo.New(ctx, repoURL, repoOpts...)
+	if err != nil {
+		return nil, res, err
+	}
+
+	source := r.GetSourceBranch(opts.SourceBranch)
+	if source == nil {
+		return nil, res, ErrSourceBranchNotFound
+	}
+	res.SourceHash = source.Hash().String()
+
+	if line, ok, err := hotfixLine(opts.Line, source, scheme); err != nil {
+		return nil, res, err
+	} else if ok {
+		if err := r.SetHotfixLine(line); err != nil {
+			return nil, res, err
+		}
+		res.Line = line.String()
+	}
+
+	// snapshots are based on the latest release, regardless of whether it is a branch or a tag
+	if opts.CreateBranch || opts.Snapshot {
+		r.GetVersionBranches(opts.TargetBranch)
+	}
+	if opts.CreateTag || opts.Snapshot || opts.versionTags {
+		r.GetVersionTags()
+	}
+	setPrevious(&res, r.GetLatestVersionReference(), opts.Naming, scheme)
+	if opts.Snapshot {
+		if err := planSnapshot(ctx, r, &res, opts); err != nil {
+			return nil, res, err
+		}
+		return r, res, nil
+	}
+
+	res.UpToDate = r.IsReleased() && !opts.Force
+	next, err := nextVersion(r, opts, scheme, res.UpToDate)
+	if err != nil {
+		return nil, res, err
+	}
+
+	createBranch := opts.CreateBranch
+	if lineBranch, line, ok := existingLineBranch(r, opts, next); ok && res.Line == "" {
+		// the version belongs to an existing line branch like release/v1.4, so it is released as tag on the head of the line branch
+		if !opts.CreateTag {
+			return nil, res, fmt.Errorf("%w: %s", ErrLineBranchTag, lineBranch.Name().Short())
+		}
+		if err := r.SetHotfixLine(line); err != nil {
+			return nil, res, err
+		}
+		res.SourceBranch, res.SourceHash, res.Line = lineBranch.Name().Short(), lineBranch.Hash().String(), line.String()
+		r.GetVersionBranches(opts.TargetBranch)
+		r.GetVersionTags()
+		setPrevious(&res, r.GetLatestVersionReference(), opts.Naming, scheme)
+		res.UpToDate = r.IsReleased() && !opts.Force
+		if next, err = nextVersion(r, opts, scheme, res.UpToDate); err != nil {
+			return nil, res, err
+		}
+		createBranch = false
+	}
+	res.NextVersion, res.SkippedVersions = next, r.SkippedVersions()
+
+	// the target is only known to the remote, if release branches are used
+	var target string
+	if opts.CreateBranch {
+		target = opts.TargetBranch
+	}
+	if createBranch {
+		if res.Branch, err = opts.Naming.BranchName(target, next); err != nil {
+			return nil, res, err
+		}
+	}
+	if opts.CreateTag {
+		if res.Tag, err = opts.Naming.TagName(target, next); err != nil {
+			return nil, res, err
+		}
+		res.TagMessage = opts.TagMessage
+	}
+	return r, res, nil
+}
+
+// setPrevious sets the previous version of res to the latest release ref, nil clears it
+func setPrevious(res *Result, ref *plumbing.Reference, naming remote.Naming, scheme version.Scheme) {
+	res.PreviousVersion, res.PreviousRelease = "", ""
+	if ref == nil {
+		return
+	}
+	res.PreviousVersion = ref.Name().Short()
+	res.PreviousRelease, _ = naming.Granularity.RefVersion(ref, scheme)
+}
+
+// nextVersion returns the explicit version or the next version of the latest release
+func nextVersion(r *repo.Repo, opts Options, scheme version.Scheme, upToDate bool) (string, error) {
+	switch {
+	case opts.Version != "" && upToDate:
+		// nothing is released, so repeating a run with an explicit version doesn't fail because it exists now
+		return scheme.Parse(opts.Version)
+	case opts.Version != "":
+		return r.ReleaseVersion(opts.Version)
+	default:
+		return r.NextReleaseVersion(opts.NextVersion)
+	}
+}
+
+// existingLineBranch returns the line branch of next like release/v1.4 for v1.4.2, if release branches are created per line,
+// the branch exists already and next isn't the first version of the line. The line branch becomes the source branch of r.
+func existingLineBranch(r *repo.Repo, opts Options, next string) (*plumbing.Reference, version.Line, bool) {
+	if !opts.CreateBranch {
+		return nil, version.Line{}, false
+	}
+	v, err := version.Parse(next)
+	if err != nil {
+		return nil, version.Line{}, false
+	}
+	line, ok := opts.Naming.Granularity.Line(v)
+	if !ok || v == line.First() {
+		return nil, version.Line{}, false
+	}
+	name, err := opts.Naming.BranchName(opts.TargetBranch, next)
+	if err != nil {
+		return nil, version.Line{}, false
+	}
+	branch := r.GetSourceBranch(name)
+	if branch == nil || !branch.Name().IsBranch() {
+		return nil, version.Line{}, false
+	}
+	return branch, line, true
+}
+
+// hotfixLine returns the release line of a hotfix, which is either given explicitly or the line of a versioned source branch
+func hotfixLine(line string, source *plumbing.Reference, scheme version.Scheme) (version.Line, bool, error) {
+	if line != "" {
+		l, err := version.ParseLine(line)
+		return l, err == nil, err
+	}
+	if _, ok := scheme.(version.SemVer); !ok {
+		return version.Line{}, false, nil
+	}
+	v, ok := version.FromRef(source.Name().Short())
+	return version.LineOf(v, version.Minor), ok, nil
+}
+
+// planSnapshot determines the snapshot version of the source branch, its tag and the snapshot tags to prune
+func planSnapshot(ctx context.Context, r *repo.Repo, res *Result, opts Options) error {
+	if opts.Version != "" {
+		return ErrSnapshotVersion
+	}
+	next, err := r.SnapshotVersion(ctx, opts.NextVersion)
+	if err != nil {
+		return err
+	}
+	res.Snapshot = true
+	res.NextVersion = next
+	if opts.SnapshotTagPrefix == "" {
+		return nil
+	}
+	res.Tag = opts.SnapshotTagPrefix + next
+	res.TagMessage = opts.TagMessage
+	res.UpToDate = r.TagExists(res.Tag)
+	if opts.SnapshotRetention > 0 {
+		for _, ref := range r.SnapshotTags(opts.SnapshotTagPrefix, opts.now().Add(-opts.SnapshotRetention)) {
+			// an old commit must not lose the snapshot tag created for it right now
+			if name := ref.Name().Short(); name != res.Tag {
+				res.Pruned = append(res.Pruned, name)
+			}
+		}
+	}
+	return nil
+}
+
+// releaseSnapshot pushes the snapshot tag if create is set and deletes the outdated snapshot tags
+func releaseSnapshot(ctx context.Context, r *repo.Repo, res Result, create bool) error {
+	if create {
+		if err := r.CreateSnapshotTag(ctx, res.Tag); err != nil {
+			return err
+		}
+	}
+	names := make([]plumbing.ReferenceName, len(res.Pruned))
+	for i, name := range res.Pruned {
+		names[i] = plumbing.NewTagReferenceName(name)
+	}
+	return r.DeleteReferences(ctx, names...)
+}
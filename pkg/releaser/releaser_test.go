@@ -0,0 +1,422 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRemote struct {
+	refs       []*plumbing.Reference
+	created    []string
+	deleted    []string
+	commitTime time.Time
+	// commitFetches counts the calls of CommitTimes
+	commitFetches int
+	// tagMessages are the messages of annotated tags by short name
+	tagMessages map[string]string
+	// files are the contents of the files of all commits by path
+	files map[string]string
+}
+
+func (f *fakeRemote) CreateBranchAndTag(_ context.Context, _ *plumbing.Reference, target, version string, branch, tag bool) error {
+	f.created = append(f.created, fmt.Sprintf("%s %s %v %v", target, version, branch, tag))
+	return nil
+}
+
+func (f *fakeRemote) GetAllRemoteBranchesAndTags(context.Context, string) ([]*plumbing.Reference, error) {
+	return f.refs, nil
+}
+
+func (f *fakeRemote) GetStorer() storage.Storer {
+	return memory.NewStorage()
+}
+
+func (f *fakeRemote) CreateTag(_ context.Context, _ *plumbing.Reference, name, _ string) error {
+	f.created = append(f.created, "tag "+name)
+	return nil
+}
+
+func (f *fakeRemote) DeleteReferences(_ context.Context, names ...plumbing.ReferenceName) error {
+	for _, name := range names {
+		f.deleted = append(f.deleted, name.String())
+	}
+	return nil
+}
+
+func (f *fakeRemote) CommitTimes(_ context.Context, refs ...*plumbing.Reference) (map[plumbing.ReferenceName]time.Time, error) {
+	f.commitFetches++
+	times := make(map[plumbing.ReferenceName]time.Time, len(refs))
+	for _, ref := range refs {
+		times[ref.Name()] = f.commitTime
+	}
+	return times, nil
+}
+
+func (f *fakeRemote) TagMessages(_ context.Context, names ...plumbing.ReferenceName) (map[plumbing.ReferenceName]string, error) {
+	messages := make(map[plumbing.ReferenceName]string, len(names))
+	for _, name := range names {
+		messages[name] = f.tagMessages[name.Short()]
+	}
+	return messages, nil
+}
+
+func (f *fakeRemote) CreateReference(_ context.Context, ref *plumbing.Reference) error {
+	f.created = append(f.created, ref.Name().String())
+	return nil
+}
+
+func (f *fakeRemote) ReadFile(_ context.Context, _ *plumbing.Reference, path string) (string, error) {
+	content, ok := f.files[path]
+	if !ok {
+		return "", object.ErrFileNotFound
+	}
+	return content, nil
+}
+
+func TestPlan_repository_not_found(t *testing.T) {
+	res, err := Plan(context.Background(), "file:///i-do-not-exist.git", Options{SourceBranch: "main"})
+
+	var notFoundErr *remote.NotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+	assert.Equal(t, Result{RepoURL: "file:///i-do-not-exist.git", SourceBranch: "main"}, res)
+}
+
+func TestPlan_source_branch_not_found(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{plumbing.NewHashReference("refs/heads/develop", plumbing.NewHash("a1"))}}
+
+	res, err := Plan(context.Background(), "https://github.com/acme/api.git", Options{SourceBranch: "main", Remote: fake})
+
+	assert.ErrorIs(t, err, ErrSourceBranchNotFound)
+	assert.Equal(t, Result{RepoURL: "https://github.com/acme/api.git", SourceBranch: "main"}, res)
+}
+
+func TestRelease_remote(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2")),
+		plumbing.NewHashReference("refs/tags/v1.2.0", plumbing.NewHash("a1")),
+	}}
+
+	res, err := Release(context.Background(), "https://github.com/acme/api.git", Options{SourceBranch: "main", CreateTag: true, NextVersion: repo.PATCH, Remote: fake})
+
+	assert.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, "v1.2.0", res.PreviousVersion)
+	assert.Equal(t, "v1.2.1", res.Tag)
+	assert.Equal(t, []string{" v1.2.1 false true"}, fake.created)
+}
+
+func TestRelease_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := Release(ctx, "file:///i-do-not-exist.git", Options{SourceBranch: "main", Logger: &log.Logger})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, res.Created)
+}
+
+func TestOptions_logger(t *testing.T) {
+	assert.NotNil(t, Options{}.logger())
+	assert.Equal(t, &log.Logger, Options{Logger: &log.Logger}.logger())
+}
+
+func TestRelease_hooks(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2"))}}
+	var calls []string
+	opts := Options{
+		SourceBranch: "main",
+		CreateTag:    true,
+		Remote:       fake,
+		PreRelease: func(_ context.Context, res Result) error {
+			calls = append(calls, "pre "+res.Tag)
+			return errors.New("artifact missing")
+		},
+		PostRelease: func(_ context.Context, res Result) error {
+			calls = append(calls, "post "+res.Tag)
+			return nil
+		},
+	}
+
+	res, err := Release(context.Background(), "https://github.com/acme/api.git", opts)
+
+	assert.ErrorIs(t, err, ErrPreReleaseFailed)
+	assert.False(t, res.Created)
+	assert.Empty(t, fake.created)
+	assert.Equal(t, []string{"pre v1.0.0"}, calls)
+
+	calls = nil
+	opts.PreRelease = nil
+	res, err = Release(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, []string{"post v1.0.0"}, calls)
+	assert.Equal(t, []string{"refs/tags/v1.0.0"}, res.Refs())
+}
+
+func TestRelease_annotated_tag(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.AnnotatedTag("v1.0.0", "first release"), gittest.Commit("feature"), gittest.Branch("main"))
+	opts := Options{SourceBranch: "main", CreateTag: true, NextVersion: repo.MINOR, TagMessage: "Released during freeze holidays: hotfix"}
+
+	res, err := Release(context.Background(), r.URL(), opts)
+
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, "Released during freeze holidays: hotfix", res.TagMessage)
+	repository, err := git.PlainOpen(r.Path())
+	require.NoError(t, err)
+	tag, err := repository.TagObject(plumbing.NewHash(r.Hash("v1.1.0")))
+	require.NoError(t, err, "v1.1.0 is an annotated tag")
+	assert.Equal(t, "Released during freeze holidays: hotfix\n", tag.Message)
+	assert.Equal(t, r.Head(), tag.Target.String())
+
+	// annotated tags are compared by the tagged commit
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "v1.1.0", res.PreviousVersion)
+	assert.Equal(t, []string{"v1.0.0", "v1.1.0"}, r.Tags())
+}
+
+func TestRelease_policy(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2")),
+		plumbing.NewHashReference("refs/tags/v1.2.0", plumbing.NewHash("a1")),
+	}}
+	errRefused := errors.New("refused")
+	var checked []string
+	opts := Options{
+		SourceBranch: "main",
+		CreateTag:    true,
+		NextVersion:  repo.MAJOR,
+		Remote:       fake,
+		Policy: func(_ context.Context, res Result) error {
+			checked = append(checked, res.NextVersion)
+			return errRefused
+		},
+		PreRelease: func(context.Context, Result) error {
+			t.Error("the pre-release hook must not run for a refused release")
+			return nil
+		},
+	}
+
+	res, err := Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, errRefused)
+	assert.Equal(t, "v2.0.0", res.NextVersion)
+
+	res, err = Release(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, errRefused)
+	assert.False(t, res.Created)
+	assert.Empty(t, fake.created)
+	assert.Equal(t, []string{"v2.0.0", "v2.0.0"}, checked)
+
+	// releases of up to date repos aren't checked
+	fake.refs[1] = plumbing.NewHashReference("refs/tags/v1.2.0", plumbing.NewHash("b2"))
+	res, err = Release(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Len(t, checked, 2)
+}
+
+func TestRelease_calver(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2")),
+		plumbing.NewHashReference("refs/tags/v1.4.0", plumbing.NewHash("a0")),
+		plumbing.NewHashReference("refs/tags/2026.10.3", plumbing.NewHash("a1")),
+	}}
+	calver, err := version.NewCalVer("YYYY.0M.MICRO", func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) })
+	require.NoError(t, err)
+
+	res, err := Release(context.Background(), "https://github.com/acme/app.git", Options{SourceBranch: "main", CreateTag: true, NextVersion: repo.PATCH, Scheme: calver, Remote: fake})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2026.10.3", res.PreviousVersion)
+	assert.Equal(t, "2026.10.4", res.Tag)
+	assert.Equal(t, []string{" 2026.10.4 false true"}, fake.created)
+}
+
+func TestRelease_snapshot(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Tag("v1.3.0"),
+		gittest.Tag("nightly/v1.3.0-dev.20191231+gabcdef0"), gittest.Tag("nightly/v1.4.0-dev.20991231+gabcdef0"),
+		gittest.Commit("feature"), gittest.Branch("main"),
+	)
+	want := "v1.4.0-dev.20200101+g" + r.Head()[:7]
+
+	res, err := Plan(context.Background(), r.URL(), Options{SourceBranch: "main", NextVersion: repo.MINOR, Snapshot: true})
+	require.NoError(t, err)
+	assert.True(t, res.Snapshot)
+	assert.Equal(t, "v1.3.0", res.PreviousVersion)
+	assert.Equal(t, want, res.NextVersion)
+	assert.Empty(t, res.Tag)
+
+	// the day after the commit, so only the snapshot of 2019-12-31 is older than the retention
+	now := func() time.Time { return time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC) }
+	opts := Options{SourceBranch: "main", CreateBranch: true, NextVersion: repo.MINOR, Snapshot: true, SnapshotTagPrefix: "nightly/", SnapshotRetention: 24 * time.Hour, Now: now}
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	assert.Equal(t, "nightly/"+want, res.Tag)
+	assert.Equal(t, []string{"nightly/v1.3.0-dev.20191231+gabcdef0"}, res.Pruned)
+	assert.Equal(t, []string{"nightly/" + want, "nightly/v1.4.0-dev.20991231+gabcdef0", "v1.3.0"}, r.Tags())
+	assert.Equal(t, []string{"main"}, r.Branches(), "snapshots don't create release branches")
+	assert.Equal(t, r.Head(), r.Hash("nightly/"+want))
+
+	// snapshot tags aren't releases, so the next snapshot of the same commit is up to date
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "v1.3.0", res.PreviousVersion)
+	assert.Equal(t, want, res.NextVersion)
+}
+
+func TestPlan_snapshot_errors(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2"))}}
+
+	_, err := Plan(context.Background(), "https://github.com/acme/api.git", Options{SourceBranch: "main", Snapshot: true, Version: "v2.0.0", Remote: fake})
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+
+	calver, err := version.NewCalVer("", nil)
+	require.NoError(t, err)
+	_, err = Plan(context.Background(), "https://github.com/acme/api.git", Options{SourceBranch: "main", Snapshot: true, Scheme: calver, Remote: fake})
+	assert.ErrorIs(t, err, repo.ErrSnapshotScheme)
+}
+
+func TestRelease_snapshot_policy_does_not_apply(t *testing.T) {
+	fake := &fakeRemote{
+		refs:       []*plumbing.Reference{plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1"))},
+		commitTime: time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC),
+	}
+	opts := Options{
+		SourceBranch:      "main",
+		NextVersion:       repo.MINOR,
+		Snapshot:          true,
+		SnapshotTagPrefix: "nightly/",
+		Remote:            fake,
+		Policy: func(context.Context, Result) error {
+			return errors.New("release freeze")
+		},
+	}
+
+	res, err := Release(context.Background(), "https://github.com/acme/api.git", opts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.1.0-dev.20261017+gb2c3d4e", res.NextVersion)
+	assert.Equal(t, []string{"tag nightly/v0.1.0-dev.20261017+gb2c3d4e"}, fake.created)
+	assert.Empty(t, fake.deleted)
+}
+
+func TestRelease_hotfix(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("release/v1.3.2"), gittest.Tag("v1.3.2"),
+		gittest.Commit("feature"), gittest.Branch("main"), gittest.Branch("release/v1.5.0"), gittest.Tag("v1.5.0"),
+		gittest.Checkout("release/v1.3.2"), gittest.Commit("fix"), gittest.Branch("release/v1.3.2"),
+	)
+	opts := Options{SourceBranch: "release/v1.3.2", TargetBranch: "release", CreateBranch: true, CreateTag: true, NextVersion: repo.PATCH}
+
+	res, err := Release(context.Background(), r.URL(), opts)
+
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3", res.Line)
+	assert.Equal(t, "v1.3.2", res.PreviousVersion)
+	assert.Equal(t, "v1.3.3", res.NextVersion)
+	assert.Equal(t, r.Hash("release/v1.3.2"), r.Hash("v1.3.3"))
+	assert.Equal(t, r.Hash("release/v1.3.2"), r.Hash("release/v1.3.3"))
+
+	// the hotfix branch is released now
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "release/v1.3.3", res.PreviousVersion)
+}
+
+func TestPlan_hotfix_line(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("b2")),
+		plumbing.NewHashReference("refs/tags/v1.3.2", plumbing.NewHash("a1")),
+		plumbing.NewHashReference("refs/tags/v1.3.4-rc.1", plumbing.NewHash("a2")),
+		plumbing.NewHashReference("refs/tags/v1.4.0", plumbing.NewHash("a3")),
+		plumbing.NewHashReference("refs/heads/release/v1.4.1", plumbing.NewHash("a4")),
+	}}
+	opts := Options{SourceBranch: "main", CreateTag: true, NextVersion: repo.PATCH, Line: "v1.3", Remote: fake}
+
+	res, err := Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.4-rc.1", res.PreviousVersion)
+	assert.Equal(t, "v1.3.4", res.NextVersion)
+
+	opts.NextVersion = repo.MINOR
+	_, err = Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, repo.ErrHotfixBump)
+
+	opts.NextVersion, opts.Version = repo.PATCH, "v1.4.2"
+	_, err = Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, repo.ErrOutsideLine)
+
+	opts.Version, opts.Line = "", "v1.2"
+	_, err = Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, repo.ErrLineNotReleased)
+
+	opts.Line, opts.SourceBranch = "v1.3", "release/v1.4.1"
+	_, err = Plan(context.Background(), "https://github.com/acme/api.git", opts)
+	assert.ErrorIs(t, err, repo.ErrOutsideLine)
+}
+
+func TestRelease_line_branches(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("release/v1.4"), gittest.Tag("v1.4.0"),
+		gittest.Commit("feature"), gittest.Branch("main"),
+		gittest.Checkout("release/v1.4"), gittest.Commit("fix"), gittest.Branch("release/v1.4"),
+	)
+	opts := Options{SourceBranch: "main", TargetBranch: "release", CreateBranch: true, CreateTag: true, NextVersion: repo.PATCH, Naming: remote.Naming{Granularity: remote.PerMinor}}
+
+	res, err := Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.4", res.SourceBranch)
+	assert.Equal(t, "v1.4", res.Line)
+	assert.Equal(t, "v1.4.0", res.PreviousVersion)
+	assert.Equal(t, "v1.4.1", res.Tag)
+	assert.Empty(t, res.Branch, "patch releases don't create a branch")
+	assert.Equal(t, r.Hash("release/v1.4"), r.Hash("v1.4.1"))
+
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Equal(t, "v1.4.1", res.PreviousVersion)
+
+	opts.NextVersion = repo.MINOR
+	res, err = Release(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.5", res.Branch)
+	assert.Equal(t, "v1.5.0", res.Tag)
+	assert.Equal(t, []string{"main", "release/v1.4", "release/v1.5"}, r.Branches())
+	assert.Equal(t, r.Hash("main"), r.Hash("release/v1.5"))
+	assert.Equal(t, r.Hash("main"), r.Hash("v1.5.0"))
+
+	opts.NextVersion, opts.CreateTag = repo.PATCH, false
+	_, err = Plan(context.Background(), r.URL(), opts)
+	assert.ErrorIs(t, err, ErrLineBranchTag)
+
+	// without tags, the line branch is the previous release
+	opts.NextVersion = repo.MAJOR
+	res, err = Plan(context.Background(), r.URL(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.5", res.PreviousVersion)
+	assert.Equal(t, "v1.5.0", res.PreviousRelease)
+	assert.Equal(t, "v2.0.0", res.NextVersion)
+}
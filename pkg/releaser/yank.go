@@ -0,0 +1,120 @@
+package releaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// YankOptions configure Yank
+type YankOptions struct {
+	// Version is the bad release like v1.0.5
+	Version string
+	// Record records the yank as reference below remote.YankedPrefix, so the version is never released again
+	Record bool
+	// DryRun only determines the release branch and tag without deleting anything
+	DryRun bool
+}
+
+// YankResult describes a planned or performed yank
+type YankResult struct {
+	RepoURL string `json:"repo_url"`
+	// Version is the yanked version
+	Version string `json:"version"`
+	// Deleted are the release branch and tag of the version
+	Deleted []string `json:"deleted"`
+	// Retract is the retract directive proposed for the go.mod of a yanked Go module
+	Retract string `json:"retract,omitempty"`
+	// UpToDate is set if the version is already yanked, nothing is deleted in this case
+	UpToDate bool `json:"up_to_date"`
+	// Yanked is set once the release branch and tag have been deleted
+	Yanked bool `json:"yanked"`
+}
+
+// ErrReleaseNotFound is returned by Yank if there is neither a release branch nor a tag of the version
+var ErrReleaseNotFound = errors.New("release not found")
+
+// Yank deletes the tag and the release branch of a bad release like v1.0.5 of repoURL with a single push. Release branches of a line
+// like release/v1.0 are kept, they contain further releases. A yanked version without branch or tag is up to date.
+// For Go modules the retract directive for go.mod is proposed, the module proxy keeps serving deleted tags.
+// Only Options.TargetBranch, Scheme, Naming, Auth, Retry, Logger and Remote apply.
+func Yank(ctx context.Context, repoURL string, opts Options, yank YankOptions) (YankResult, error) {
+	res := YankResult{RepoURL: repoURL}
+	scheme := opts.Scheme
+	if scheme == nil {
+		scheme = version.SemVer{}
+	}
+	v, err := scheme.Parse(yank.Version)
+	if err != nil {
+		return res, err
+	}
+	res.Version = v
+
+	repoOpts := []repo.Option{repo.WithAuth(opts.Auth), repo.WithLogger(opts.logger()), repo.WithRetry(opts.Retry), repo.WithNaming(opts.Naming), repo.WithScheme(scheme)}
+	if opts.Remote != nil {
+		repoOpts = append(repoOpts, repo.WithRemote(opts.Remote))
+	}
+	r, err := repo.New(ctx, repoURL, repoOpts...)
+	if err != nil {
+		return res, err
+	}
+	var refs []*plumbing.Reference
+	for _, ref := range append(r.GetVersionBranches(opts.TargetBranch), r.GetVersionTags()...) {
+		// line branches like release/v1.0 don't have a version of their own
+		if name, ok := scheme.FromRef(ref.Name().Short()); ok && scheme.Compare(name, v) == 0 {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		if r.IsYanked(v) {
+			res.UpToDate = true
+			return res, nil
+		}
+		return res, fmt.Errorf("%w: %s", ErrReleaseNotFound, v)
+	}
+	for _, ref := range refs {
+		res.Deleted = append(res.Deleted, ref.Name().Short())
+	}
+	if _, semver := scheme.(version.SemVer); semver {
+		module, err := goModule(ctx, r, refs[0])
+		if err != nil {
+			return res, err
+		}
+		if module != "" {
+			res.Retract = "retract " + v
+			opts.logger().Warn().Msgf("The module proxy keeps serving %s of the Go module %s, add `%s` to its go.mod and release a new version", v, module, res.Retract)
+		}
+	}
+	if yank.DryRun {
+		return res, nil
+	}
+
+	if err := r.Yank(ctx, v, yank.Record, refs...); err != nil {
+		return res, err
+	}
+	res.Yanked = true
+	return res, nil
+}
+
+// goModule returns the module path of the go.mod of ref, empty if ref isn't a Go module
+func goModule(ctx context.Context, r *repo.Repo, ref *plumbing.Reference) (string, error) {
+	content, err := r.ReadFile(ctx, ref, "go.mod")
+	if errors.Is(err, object.ErrFileNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,75 @@
+package releaser
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/repo"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYank(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.CommitFile("initial", "go.mod", "module github.com/acme/api\n\ngo 1.22\n"), gittest.Branch("main"),
+		gittest.Tag("v1.0.4"), gittest.Branch("release/v1.0.4"),
+		gittest.CommitFile("bad", "CHANGES", "bad\n"), gittest.Tag("v1.0.5"), gittest.Branch("release/v1.0.5"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	opts := Options{TargetBranch: "release"}
+	yank := YankOptions{Version: "1.0.5", Record: true, DryRun: true}
+
+	res, err := Yank(ctx, r.URL(), opts, yank)
+	require.NoError(t, err)
+	assert.False(t, res.Yanked)
+	assert.Equal(t, "v1.0.5", res.Version)
+	assert.Equal(t, []string{"release/v1.0.5", "v1.0.5"}, res.Deleted)
+	assert.Equal(t, "retract v1.0.5", res.Retract)
+	assert.Equal(t, []string{"v1.0.4", "v1.0.5"}, r.Tags())
+
+	yank.DryRun = false
+	res, err = Yank(ctx, r.URL(), opts, yank)
+	require.NoError(t, err)
+	assert.True(t, res.Yanked)
+	assert.Equal(t, []string{"v1.0.4"}, r.Tags())
+	assert.Equal(t, []string{"main", "release/v1.0.4"}, r.Branches())
+	assert.Equal(t, r.Hash("main"), r.Hash("refs/yanked/v1.0.5"))
+
+	res, err = Yank(ctx, r.URL(), opts, yank)
+	require.NoError(t, err)
+	assert.True(t, res.UpToDate)
+	assert.Empty(t, res.Deleted)
+
+	// the yanked version isn't released again
+	release, err := Release(ctx, r.URL(), Options{SourceBranch: "main", TargetBranch: "release", CreateTag: true, NextVersion: repo.PATCH})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.6", release.NextVersion)
+}
+
+func TestYank_fake(t *testing.T) {
+	fake := &fakeRemote{refs: []*plumbing.Reference{
+		plumbing.NewHashReference("refs/heads/main", plumbing.NewHash("c3")),
+		plumbing.NewHashReference("refs/heads/release/v1.0", plumbing.NewHash("c2")),
+		plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("c1")),
+		plumbing.NewHashReference("refs/tags/v1.0.1", plumbing.NewHash("c2")),
+	}}
+	ctx := context.Background()
+	opts := Options{Remote: fake, TargetBranch: "release", Naming: remote.Naming{Granularity: remote.PerMinor}}
+
+	_, err := Yank(ctx, "https://github.com/acme/api.git", opts, YankOptions{Version: "latest"})
+	assert.ErrorIs(t, err, version.ErrInvalid)
+	_, err = Yank(ctx, "https://github.com/acme/api.git", opts, YankOptions{Version: "v1.0.2"})
+	assert.ErrorIs(t, err, ErrReleaseNotFound)
+
+	// the line branch is kept, it contains v1.0.0 as well
+	res, err := Yank(ctx, "https://github.com/acme/api.git", opts, YankOptions{Version: "v1.0.1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.0.1"}, res.Deleted)
+	assert.Empty(t, res.Retract, "no Go module")
+	assert.Equal(t, []string{"refs/tags/v1.0.1"}, fake.deleted)
+	assert.Empty(t, fake.created, "the yank isn't recorded")
+}
@@ -0,0 +1,86 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// ErrNotSupported is returned if the remote doesn't implement the optional interface of an operation
+var ErrNotSupported = errors.New("not supported by remote")
+
+// AuthError is returned if the remote rejected or couldn't use the credentials
+type AuthError struct {
+	URL string
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed for %s: %v", e.URL, e.Err)
+}
+
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// NotFoundError is returned if the remote repository doesn't exist or isn't visible with the used credentials
+type NotFoundError struct {
+	URL string
+	Err error
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("repository %s not found: %v", e.URL, e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+// NetworkError is returned if the remote couldn't be reached or the connection dropped
+type NetworkError struct {
+	URL string
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error for %s: %v", e.URL, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// classifyError wraps err of a remote operation on url into an AuthError, NotFoundError or NetworkError,
+// other errors are returned unchanged
+func classifyError(url string, err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, transport.ErrAuthenticationRequired),
+		errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod),
+		strings.Contains(msg, "unable to authenticate"),
+		strings.Contains(msg, "ssh agent"),
+		strings.Contains(msg, "ssh_auth_sock"):
+		return &AuthError{URL: url, Err: err}
+	case errors.Is(err, transport.ErrRepositoryNotFound),
+		strings.Contains(msg, "repository not found"),
+		strings.Contains(msg, "does not appear to be a git repository"):
+		return &NotFoundError{URL: url, Err: err}
+	}
+	var netErr net.Error
+	if IsTransient(err) || errors.As(err, &netErr) {
+		return &NetworkError{URL: url, Err: err}
+	}
+	return err
+}
+
+// MergeConflictError is returned by Workspace.Merge if both sides changed the same lines of files differently
+type MergeConflictError struct {
+	Into  string
+	Paths []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("merge conflict in %s: %s", e.Into, strings.Join(e.Paths, ", "))
+}
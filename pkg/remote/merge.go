@@ -0,0 +1,488 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Workspace is an in-memory clone of a remote repository. It merges branches and creates tags locally,
+// Push pushes all updated references at once.
+type Workspace struct {
+	repo   *git.Repository
+	remote *GitRepo
+	// updates are the references changed by Merge and Tag in the order of their changes
+	updates []plumbing.ReferenceName
+}
+
+// Clone clones all branches and tags of repoURL into memory, independent of the references listed by GetAllRemoteBranchesAndTags
+func (m *GitRepo) Clone(ctx context.Context, repoURL string) (*Workspace, error) {
+	m.url = repoURL
+	var repo *git.Repository
+	err := m.Retry.do(ctx, m.log(), "Cloning", func() error {
+		var err error
+		repo, err = git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{URL: repoURL, Auth: m.Auth, Tags: git.AllTags})
+		return err
+	}, nil)
+	if err != nil {
+		return nil, classifyError(repoURL, err)
+	}
+	return &Workspace{repo: repo, remote: m}, nil
+}
+
+// Branch returns the head of the branch name including the local changes
+func (w *Workspace) Branch(name string) (plumbing.Hash, error) {
+	for _, ref := range []plumbing.ReferenceName{plumbing.NewBranchReferenceName(name), plumbing.NewRemoteReferenceName("origin", name)} {
+		if r, err := w.repo.Reference(ref, true); err == nil {
+			return r.Hash(), nil
+		}
+	}
+	return plumbing.ZeroHash, fmt.Errorf("branch %s not found", name)
+}
+
+// Merge merges the commit from into the branch into with a merge commit, even if into could be fast-forwarded.
+// Changes of both sides to the same lines of a file are a conflict, which is returned as MergeConflictError. If from is already merged,
+// nothing is changed. The new head of into is returned.
+func (w *Workspace) Merge(from plumbing.Hash, into, message string) (plumbing.Hash, error) {
+	head, err := w.Branch(into)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	ours, err := w.repo.CommitObject(head)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	theirs, err := w.repo.CommitObject(from)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if merged, err := theirs.IsAncestor(ours); err != nil || merged || head == from {
+		return head, err
+	}
+
+	var base *object.Tree
+	if bases, err := ours.MergeBase(theirs); err != nil {
+		return plumbing.ZeroHash, err
+	} else if len(bases) > 0 {
+		if base, err = bases[0].Tree(); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	tree, err := w.mergeTrees(into, base, ours, theirs)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	signature := Tagger
+	signature.When = time.Now()
+	commit := object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      strings.TrimRight(message, "\n") + "\n",
+		TreeHash:     tree,
+		ParentHashes: []plumbing.Hash{head, from},
+	}
+	hash, err := encodeObject(w.repo.Storer, &commit)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.update(plumbing.NewHashReference(plumbing.NewBranchReferenceName(into), hash)); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	w.remote.log().Info().Msgf("Merged %s into %s", from, into)
+	return hash, nil
+}
+
+// Tag creates the tag name pointing to target, it is annotated if message is set
+func (w *Workspace) Tag(name string, target plumbing.Hash, message string) error {
+	hash := target
+	if message != "" {
+		var err error
+		if hash, err = newAnnotatedTag(w.repo.Storer, name, target, message); err != nil {
+			return err
+		}
+	}
+	return w.update(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash))
+}
+
+// Push pushes all references changed by Merge and Tag atomically, either all of them are updated on the remote or none
+func (w *Workspace) Push(ctx context.Context) error {
+	if len(w.updates) == 0 {
+		return nil
+	}
+	refspecs := make([]config.RefSpec, len(w.updates))
+	for i, name := range w.updates {
+		refspecs[i] = config.RefSpec(fmt.Sprintf("%s:%s", name, name))
+	}
+	m := w.remote
+	err := m.Retry.do(ctx, m.log(), fmt.Sprintf("Pushing %d references", len(w.updates)), func() error {
+		return w.repo.PushContext(ctx, &git.PushOptions{RefSpecs: refspecs, Auth: m.Auth, Atomic: true})
+	}, func() bool {
+		return w.remoteHasUpdates(ctx)
+	})
+	if err != nil {
+		return classifyError(m.url, err)
+	}
+	for _, name := range w.updates {
+		m.log().Info().Msgf("Successfully pushed %s", name.Short())
+	}
+	return nil
+}
+
+func (w *Workspace) update(ref *plumbing.Reference) error {
+	if err := w.repo.Storer.SetReference(ref); err != nil {
+		return err
+	}
+	for _, name := range w.updates {
+		if name == ref.Name() {
+			return nil
+		}
+	}
+	w.updates = append(w.updates, ref.Name())
+	return nil
+}
+
+// remoteHasUpdates checks if a former push already updated all references on the remote
+func (w *Workspace) remoteHasUpdates(ctx context.Context) bool {
+	rem, err := w.repo.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return false
+	}
+	refs, err := rem.ListContext(ctx, &git.ListOptions{Auth: w.remote.Auth})
+	if err != nil {
+		return false
+	}
+	remote := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	for _, r := range refs {
+		remote[r.Name()] = r.Hash()
+	}
+	for _, name := range w.updates {
+		local, err := w.repo.Storer.Reference(name)
+		if err != nil || remote[name] != local.Hash() {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeTrees merges the trees of ours and theirs file by file. A file changed by only one side is taken from this side,
+// the lines of a text file changed by both sides are merged by mergeLines. Changes of both sides to the same lines, other files
+// changed by both sides differently and a file which is a directory on the other side are a conflict. The hash of the merged tree is returned.
+func (w *Workspace) mergeTrees(into string, base *object.Tree, ours, theirs *object.Commit) (plumbing.Hash, error) {
+	baseFiles, err := treeFiles(base)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	ourTree, err := ours.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	ourFiles, err := treeFiles(ourTree)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	theirTree, err := theirs.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	theirFiles, err := treeFiles(theirTree)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	merged := make(map[string]object.TreeEntry)
+	var conflicts []string
+	seen := make(map[string]bool)
+	for _, files := range []map[string]object.TreeEntry{baseFiles, ourFiles, theirFiles} {
+		for p := range files {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			// a missing file is an empty entry
+			b, o, t := baseFiles[p], ourFiles[p], theirFiles[p]
+			result := o
+			switch {
+			case o == t, t == b:
+			case o == b:
+				result = t
+			default:
+				// both sides changed the file, which can still be merged if they changed different lines
+				var ok bool
+				if result, ok, err = w.mergeFile(b, o, t); err != nil {
+					return plumbing.ZeroHash, err
+				} else if !ok {
+					conflicts = append(conflicts, p)
+					continue
+				}
+			}
+			if !result.Hash.IsZero() {
+				merged[p] = result
+			}
+		}
+	}
+	conflicts = append(conflicts, fileDirConflicts(merged)...)
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return plumbing.ZeroHash, &MergeConflictError{Into: into, Paths: conflicts}
+	}
+	return writeTree(w.repo.Storer, merged)
+}
+
+// fileDirConflicts returns the merged files, which are also a directory of other merged files, and these files.
+// This happens if one side adds the file x and the other side x/y, a tree can't have both.
+func fileDirConflicts(merged map[string]object.TreeEntry) []string {
+	conflicts := make(map[string]bool)
+	for p := range merged {
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			if _, ok := merged[dir]; ok {
+				conflicts[dir], conflicts[p] = true, true
+			}
+		}
+	}
+	paths := make([]string, 0, len(conflicts))
+	for p := range conflicts {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// mergeFile merges the changes of ours and theirs to the file base line by line, a missing base is an empty file.
+// Only text files with the same mode on both sides are merged, false is returned for a conflict.
+func (w *Workspace) mergeFile(base, ours, theirs object.TreeEntry) (object.TreeEntry, bool, error) {
+	if ours.Hash.IsZero() || theirs.Hash.IsZero() || ours.Mode != theirs.Mode || (ours.Mode != filemode.Regular && ours.Mode != filemode.Executable) {
+		return object.TreeEntry{}, false, nil
+	}
+	var texts [3]string
+	for i, e := range []object.TreeEntry{base, ours, theirs} {
+		if e.Hash.IsZero() {
+			continue
+		}
+		blob, err := w.repo.BlobObject(e.Hash)
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+		content, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			return object.TreeEntry{}, false, err
+		}
+		// like git, files with a NUL byte are binary and aren't merged
+		if bytes.IndexByte(content, 0) >= 0 {
+			return object.TreeEntry{}, false, nil
+		}
+		texts[i] = string(content)
+	}
+	merged, ok := mergeLines(texts[0], texts[1], texts[2])
+	if !ok {
+		return object.TreeEntry{}, false, nil
+	}
+	hash, err := writeBlob(w.repo.Storer, merged)
+	if err != nil {
+		return object.TreeEntry{}, false, err
+	}
+	return object.TreeEntry{Name: ours.Name, Mode: ours.Mode, Hash: hash}, true, nil
+}
+
+// hunk replaces the lines start to end (exclusive) of the base by lines, start equals end for insertions
+type hunk struct {
+	start, end int
+	lines      []string
+}
+
+// mergeLines performs a three-way merge of the lines of ours and theirs based on base. Changes of only one side are taken,
+// identical changes of both sides are taken once. Different changes of both sides to the same or adjacent lines are a conflict
+// like in git, false is returned in this case.
+func mergeLines(base, ours, theirs string) (string, bool) {
+	baseLines := splitLines(base)
+	a, b := hunks(base, ours), hunks(base, theirs)
+	var merged []string
+	pos := 0
+	for len(a) > 0 || len(b) > 0 {
+		// a region starts with the first hunk of both sides and grows while further hunks overlap or touch it
+		var ourRegion, theirRegion []hunk
+		var first hunk
+		if len(b) == 0 || (len(a) > 0 && a[0].start <= b[0].start) {
+			first, ourRegion, a = a[0], a[:1:1], a[1:]
+		} else {
+			first, theirRegion, b = b[0], b[:1:1], b[1:]
+		}
+		start, end := first.start, first.end
+		for grown := true; grown; {
+			grown = false
+			if len(a) > 0 && a[0].start <= end {
+				end, ourRegion, a = max(end, a[0].end), append(ourRegion, a[0]), a[1:]
+				grown = true
+			}
+			if len(b) > 0 && b[0].start <= end {
+				end, theirRegion, b = max(end, b[0].end), append(theirRegion, b[0]), b[1:]
+				grown = true
+			}
+		}
+		merged = append(merged, baseLines[pos:start]...)
+		ourLines, theirLines := applyHunks(baseLines, start, end, ourRegion), applyHunks(baseLines, start, end, theirRegion)
+		switch {
+		case len(theirRegion) == 0:
+			merged = append(merged, ourLines...)
+		case len(ourRegion) == 0, slices.Equal(ourLines, theirLines):
+			merged = append(merged, theirLines...)
+		default:
+			return "", false
+		}
+		pos = end
+	}
+	merged = append(merged, baseLines[pos:]...)
+	return strings.Join(merged, ""), true
+}
+
+// hunks returns the changes from base to side ordered by their position in base
+func hunks(base, side string) []hunk {
+	var hs []hunk
+	pos, open := 0, false
+	for _, d := range diff.Do(base, side) {
+		lines := splitLines(d.Text)
+		if d.Type == diffmatchpatch.DiffEqual {
+			pos, open = pos+len(lines), false
+			continue
+		}
+		// adjacent deletions and insertions form a single hunk
+		if !open {
+			hs, open = append(hs, hunk{start: pos, end: pos}), true
+		}
+		h := &hs[len(hs)-1]
+		if d.Type == diffmatchpatch.DiffDelete {
+			pos += len(lines)
+			h.end = pos
+		} else {
+			h.lines = append(h.lines, lines...)
+		}
+	}
+	return hs
+}
+
+// applyHunks returns the lines start to end of base with the hunks of this range applied
+func applyHunks(base []string, start, end int, hs []hunk) []string {
+	var lines []string
+	pos := start
+	for _, h := range hs {
+		lines = append(lines, base[pos:h.start]...)
+		lines = append(lines, h.lines...)
+		pos = h.end
+	}
+	return append(lines, base[pos:end]...)
+}
+
+// splitLines splits s after every newline, a last line without newline is kept
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeBlob stores content as blob and returns its hash
+func writeBlob(s storage.Storer, content string) (plumbing.Hash, error) {
+	blob := s.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	w, err := blob.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := io.WriteString(w, content); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(blob)
+}
+
+// treeFiles returns all entries of tree except directories by their path, a nil tree has no files
+func treeFiles(tree *object.Tree) (map[string]object.TreeEntry, error) {
+	files := make(map[string]object.TreeEntry)
+	if tree == nil {
+		return files, nil
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return files, nil
+			}
+			return nil, err
+		}
+		if entry.Mode != filemode.Dir {
+			entry.Name = path.Base(name)
+			files[name] = entry
+		}
+	}
+}
+
+// writeTree stores the tree of files and all its subtrees, it returns the hash of the root tree
+func writeTree(s storage.Storer, files map[string]object.TreeEntry) (plumbing.Hash, error) {
+	var entries []object.TreeEntry
+	dirs := make(map[string]map[string]object.TreeEntry)
+	for p, e := range files {
+		dir, rest, nested := strings.Cut(p, "/")
+		if !nested {
+			entries = append(entries, e)
+			continue
+		}
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string]object.TreeEntry)
+		}
+		dirs[dir][rest] = e
+	}
+	for dir, sub := range dirs {
+		hash, err := writeTree(s, sub)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		entries = append(entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+	// git sorts the entries by name, directories as if they ended with a slash
+	sortName := func(e object.TreeEntry) string {
+		if e.Mode == filemode.Dir {
+			return e.Name + "/"
+		}
+		return e.Name
+	}
+	sort.Slice(entries, func(i, j int) bool { return sortName(entries[i]) < sortName(entries[j]) })
+	return encodeObject(s, &object.Tree{Entries: entries})
+}
+
+type encoder interface {
+	Encode(plumbing.EncodedObject) error
+}
+
+func encodeObject(s storage.Storer, o encoder) (plumbing.Hash, error) {
+	eo := s.NewEncodedObject()
+	if err := o.Encode(eo); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(eo)
+}
@@ -0,0 +1,142 @@
+package remote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace_Merge(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("develop"),
+		gittest.CommitFile("feature", "docs/feature.md", "feature\n"), gittest.Branch("release/v1.1.0"),
+		gittest.Checkout("main"), gittest.CommitFile("hotfix", "HOTFIX", "hotfix\n"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	ws, err := (&GitRepo{}).Clone(ctx, r.URL())
+	require.NoError(t, err)
+
+	release, err := ws.Branch("release/v1.1.0")
+	require.NoError(t, err)
+	head, err := ws.Merge(release, "main", "Merge branch 'release/v1.1.0'")
+	require.NoError(t, err)
+	require.NoError(t, ws.Tag("v1.1.0", head, "release v1.1.0"))
+	// develop can be fast-forwarded, but git-flow always creates a merge commit
+	develop, err := ws.Merge(head, "develop", "Merge tag 'v1.1.0' into develop")
+	require.NoError(t, err)
+	assert.NotEqual(t, head, develop)
+
+	// merging again changes nothing
+	again, err := ws.Merge(release, "main", "Merge branch 'release/v1.1.0'")
+	require.NoError(t, err)
+	assert.Equal(t, head, again)
+
+	assert.Equal(t, r.Hash("main"), r.Hash("refs/heads/main"), "nothing is pushed before Push")
+	require.NoError(t, ws.Push(ctx))
+
+	assert.Equal(t, head.String(), r.Hash("main"))
+	assert.Equal(t, develop.String(), r.Hash("develop"))
+	assert.Equal(t, []string{"v1.1.0"}, r.Tags())
+	for _, branch := range []string{"main", "develop", "v1.1.0"} {
+		assert.Equal(t, "feature\n", r.File(branch, "docs/feature.md"), branch)
+		assert.Equal(t, "hotfix\n", r.File(branch, "HOTFIX"), branch)
+		assert.Equal(t, "initial\n", r.File(branch, "CHANGES"), branch)
+	}
+	assert.Equal(t, release.String(), r.Hash("release/v1.1.0"), "the release branch is kept")
+}
+
+func TestWorkspace_Merge_conflict(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"),
+		gittest.Commit("release"), gittest.CommitFile("docs", "README.md", "release\n"), gittest.Branch("release/v1.1.0"),
+		gittest.Checkout("main"), gittest.Commit("hotfix"), gittest.CommitFile("docs", "README.md", "hotfix\n"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	ws, err := (&GitRepo{}).Clone(ctx, r.URL())
+	require.NoError(t, err)
+
+	release, err := ws.Branch("release/v1.1.0")
+	require.NoError(t, err)
+	_, err = ws.Merge(release, "main", "Merge branch 'release/v1.1.0'")
+	var conflict *MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, &MergeConflictError{Into: "main", Paths: []string{"CHANGES", "README.md"}}, conflict)
+	assert.EqualError(t, err, "merge conflict in main: CHANGES, README.md")
+
+	require.NoError(t, ws.Push(ctx))
+	assert.Empty(t, r.Tags())
+
+	_, err = ws.Merge(plumbing.ZeroHash, "does-not-exist", "")
+	assert.EqualError(t, err, "branch does-not-exist not found")
+}
+
+func TestWorkspace_Merge_file_directory_conflict(t *testing.T) {
+	r := gittest.NewRepo(t,
+		gittest.Commit("initial"), gittest.Branch("main"),
+		gittest.CommitFile("docs", "docs/index.md", "release\n"), gittest.Branch("release/v1.1.0"),
+		gittest.Checkout("main"), gittest.CommitFile("docs", "docs", "hotfix\n"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	ws, err := (&GitRepo{}).Clone(ctx, r.URL())
+	require.NoError(t, err)
+
+	release, err := ws.Branch("release/v1.1.0")
+	require.NoError(t, err)
+	_, err = ws.Merge(release, "main", "Merge branch 'release/v1.1.0'")
+	var conflict *MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, &MergeConflictError{Into: "main", Paths: []string{"docs", "docs/index.md"}}, conflict)
+}
+
+func TestWorkspace_Merge_lines(t *testing.T) {
+	changelog := "# Changelog\n\n## v1.0.0\n- initial\n\nversion: 1.0.0\n"
+	r := gittest.NewRepo(t,
+		gittest.CommitFile("initial", "CHANGELOG.md", changelog), gittest.Branch("main"), gittest.Branch("develop"),
+		gittest.CommitFile("release", "CHANGELOG.md", "# Changelog\n\n## v1.1.0\n- feature\n\n## v1.0.0\n- initial\n\nversion: 1.0.0\n"), gittest.Branch("release/v1.1.0"),
+		gittest.Checkout("main"), gittest.CommitFile("hotfix", "CHANGELOG.md", "# Changelog\n\n## v1.0.0\n- initial\n\nversion: 1.0.1\n"), gittest.Branch("main"),
+	)
+	ctx := context.Background()
+	ws, err := (&GitRepo{}).Clone(ctx, r.URL())
+	require.NoError(t, err)
+
+	release, err := ws.Branch("release/v1.1.0")
+	require.NoError(t, err)
+	_, err = ws.Merge(release, "main", "Merge branch 'release/v1.1.0'")
+	require.NoError(t, err, "changes of different lines are merged")
+	require.NoError(t, ws.Push(ctx))
+
+	assert.Equal(t, "# Changelog\n\n## v1.1.0\n- feature\n\n## v1.0.0\n- initial\n\nversion: 1.0.1\n", r.File("main", "CHANGELOG.md"))
+}
+
+func Test_mergeLines(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	tests := []struct {
+		name, ours, theirs string
+		want               string
+		wantOK             bool
+	}{
+		{"different lines", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\nE\n", "A\nb\nc\nd\nE\n", true},
+		{"insertions", "a\nx\nb\nc\nd\ne\n", "a\nb\nc\nd\ny\ne\n", "a\nx\nb\nc\nd\ny\ne\n", true},
+		{"deletions", "b\nc\nd\ne\n", "a\nb\nc\nd\n", "b\nc\nd\n", true},
+		{"same change", "a\nB\nc\nd\ne\n", "a\nB\nc\nd\nE\n", "a\nB\nc\nd\nE\n", true},
+		{"missing newline", "A\nb\nc\nd\ne\n", "a\nb\nc\nd\ne", "A\nb\nc\nd\ne", true},
+		{"same line", "a\nB\nc\nd\ne\n", "a\nX\nc\nd\ne\n", "", false},
+		{"adjacent lines", "a\nB\nc\nd\ne\n", "a\nb\nC\nd\ne\n", "", false},
+		{"insertions at the same line", "a\nx\nb\nc\nd\ne\n", "a\ny\nb\nc\nd\ne\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mergeLines(base, tt.ours, tt.theirs)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// files added on both sides have an empty base
+	_, ok := mergeLines("", "a\n", "b\n")
+	assert.False(t, ok)
+}
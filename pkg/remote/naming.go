@@ -0,0 +1,153 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/fhopfensperger/git-releaser/pkg/version"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// DefaultBranchTemplate creates branches like release/v1.2.3, or v1.2.3 without a target
+	DefaultBranchTemplate = `{{if .Target}}{{.Target}}/{{end}}{{.Version}}`
+	// DefaultTagTemplate creates tags like v1.2.3
+	DefaultTagTemplate = `{{.Version}}`
+)
+
+// Granularity defines for which versions a new release branch is created
+type Granularity int
+
+const (
+	// PerPatch creates a release branch for every version like release/v1.4.2
+	PerPatch Granularity = iota
+	// PerMinor creates a release branch for every minor version like release/v1.4, patch releases are tags on it
+	PerMinor
+	// PerMajor creates a release branch for every major version like release/v1, minor and patch releases are tags on it
+	PerMajor
+)
+
+// granularities maps the names of the granularities to their values
+var granularities = map[string]Granularity{"patch": PerPatch, "minor": PerMinor, "major": PerMajor}
+
+// ParseGranularity parses patch, minor or major, empty returns PerPatch
+func ParseGranularity(s string) (Granularity, error) {
+	if s == "" {
+		return PerPatch, nil
+	}
+	g, ok := granularities[strings.ToLower(s)]
+	if !ok {
+		return PerPatch, fmt.Errorf("invalid branch granularity %q, possible values: patch, minor, major", s)
+	}
+	return g, nil
+}
+
+// Line returns the release line of the branches of v, false for PerPatch
+func (g Granularity) Line(v version.Version) (version.Line, bool) {
+	switch g {
+	case PerMinor:
+		return version.LineOf(v, version.Minor), true
+	case PerMajor:
+		return version.LineOf(v, version.Major), true
+	default:
+		return version.Line{}, false
+	}
+}
+
+// RefVersion returns the version of a branch or tag in scheme, nil uses semantic versioning.
+// With PerMinor or PerMajor, line branches like release/v1.4 or release/v1 have the first version of their line, e.g. v1.4.0.
+func (g Granularity) RefVersion(ref *plumbing.Reference, scheme version.Scheme) (string, bool) {
+	if scheme == nil {
+		scheme = version.SemVer{}
+	}
+	if v, ok := scheme.FromRef(ref.Name().Short()); ok {
+		return v, true
+	}
+	if _, semver := scheme.(version.SemVer); g == PerPatch || !semver || !ref.Name().IsBranch() {
+		return "", false
+	}
+	line, ok := version.LineFromRef(ref.Name().Short())
+	if !ok {
+		return "", false
+	}
+	return line.First().String(), true
+}
+
+// YankedPrefix is the prefix of the references recording yanked versions, e.g. refs/yanked/v1.0.5
+const YankedPrefix = "refs/yanked/"
+
+// YankedReferenceName returns the reference recording the yanked version v
+func YankedReferenceName(v string) plumbing.ReferenceName {
+	return plumbing.ReferenceName(YankedPrefix + v)
+}
+
+// Naming holds the text/template definitions used to name new release branches and tags,
+// empty templates fall back to DefaultBranchTemplate and DefaultTagTemplate
+type Naming struct {
+	BranchTemplate string
+	TagTemplate    string
+	// Granularity shortens the version of new release branches to their line, e.g. release/v1.4 for PerMinor
+	Granularity Granularity
+}
+
+// NameData is passed to the Naming templates
+type NameData struct {
+	// Target is the target branch prefix, e.g. release
+	Target string
+	// Version is the new version, e.g. v1.2.3
+	Version string
+	// Number is the new version without the leading v, e.g. 1.2.3
+	Number string
+}
+
+func newNameData(target, version string) NameData {
+	return NameData{Target: target, Version: version, Number: strings.TrimPrefix(version, "v")}
+}
+
+// Validate checks if both templates can be parsed
+func (n Naming) Validate() error {
+	if _, err := parseTemplate("branch", n.BranchTemplate, DefaultBranchTemplate); err != nil {
+		return err
+	}
+	_, err := parseTemplate("tag", n.TagTemplate, DefaultTagTemplate)
+	return err
+}
+
+// BranchName renders the name of a new release branch, with a Granularity the version is shortened to its line, e.g. v1.4
+func (n Naming) BranchName(target, v string) (string, error) {
+	if n.Granularity != PerPatch {
+		parsed, err := version.Parse(v)
+		if err != nil {
+			return "", fmt.Errorf("branch granularity requires semantic versioning: %w", err)
+		}
+		line, _ := n.Granularity.Line(parsed)
+		v = line.String()
+	}
+	return render("branch", n.BranchTemplate, DefaultBranchTemplate, newNameData(target, v))
+}
+
+// TagName renders the name of a new release tag
+func (n Naming) TagName(target, version string) (string, error) {
+	return render("tag", n.TagTemplate, DefaultTagTemplate, newNameData(target, version))
+}
+
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+	return template.New(name).Option("missingkey=error").Parse(text)
+}
+
+func render(name, text, fallback string, data NameData) (string, error) {
+	t, err := parseTemplate(name, text, fallback)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
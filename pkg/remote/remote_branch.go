@@ -1,9 +1,13 @@
 package remote
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 
@@ -11,8 +15,9 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/storage"
 
-	"golang.org/x/mod/semver"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/go-git/go-git/v5/config"
@@ -22,35 +27,107 @@ import (
 	"github.com/go-git/go-git/v5"
 )
 
+// VersionRegex matches versions anywhere in a branch or tag name.
+//
+// Deprecated: use version.FromRef, which only accepts complete semantic versions at the end of the name.
 var VersionRegex = regexp.MustCompile(`v\d+(\.\d+)+`)
 
 type CreateBranchAndTager interface {
-	CreateBranchAndTag(*plumbing.Reference, string, string, bool, bool) error
-	GetAllRemoteBranchesAndTags(repoURL string) []*plumbing.Reference
+	CreateBranchAndTag(context.Context, *plumbing.Reference, string, string, bool, bool) error
+	GetAllRemoteBranchesAndTags(ctx context.Context, repoURL string) ([]*plumbing.Reference, error)
 	GetStorer() storage.Storer
 }
 
+// The following interfaces are optional, GitRepo implements all of them.
+// Other remotes only need them for the operations using them, e.g. snapshots, promote, yank, prune and flow finish.
+
+// TagCreator creates tags without a release branch
+type TagCreator interface {
+	// CreateTag pushes the tag name pointing to the commit of ref, it is annotated with message if set
+	CreateTag(ctx context.Context, ref *plumbing.Reference, name, message string) error
+}
+
+// ReferenceCreator creates arbitrary references
+type ReferenceCreator interface {
+	// CreateReference pushes ref, e.g. the record of a yanked version, ref must point to the commit of a listed reference
+	CreateReference(ctx context.Context, ref *plumbing.Reference) error
+}
+
+// ReferenceDeleter deletes branches and tags
+type ReferenceDeleter interface {
+	// DeleteReferences deletes the branches and tags names on the remote
+	DeleteReferences(ctx context.Context, names ...plumbing.ReferenceName) error
+}
+
+// CommitTimer reads the date of commits
+type CommitTimer interface {
+	// CommitTimes returns the commit dates of the commits refs point to by reference name
+	CommitTimes(ctx context.Context, refs ...*plumbing.Reference) (map[plumbing.ReferenceName]time.Time, error)
+}
+
+// TagMessageReader reads the messages of annotated tags
+type TagMessageReader interface {
+	// TagMessages returns the messages of the annotated tags names, lightweight tags have an empty message
+	TagMessages(ctx context.Context, names ...plumbing.ReferenceName) (map[plumbing.ReferenceName]string, error)
+}
+
+// FileReader reads files of commits
+type FileReader interface {
+	// ReadFile returns the content of the file path in the commit ref points to, a missing file returns object.ErrFileNotFound
+	ReadFile(ctx context.Context, ref *plumbing.Reference, path string) (string, error)
+}
+
+// Cloner clones repositories into memory to merge branches
+type Cloner interface {
+	// Clone clones all branches and tags of repoURL into a Workspace, which pushes its changes to repoURL
+	Clone(ctx context.Context, repoURL string) (*Workspace, error)
+}
+
 type GitRemoter interface {
-	List(o *git.ListOptions) (rfs []*plumbing.Reference, err error)
-	Push(o *git.PushOptions) error
+	ListContext(ctx context.Context, o *git.ListOptions) (rfs []*plumbing.Reference, err error)
+	PushContext(ctx context.Context, o *git.PushOptions) error
 }
 type GitRepo struct {
 	remote GitRemoter
 	storer storage.Storer
+	url    string
 	Auth   transport.AuthMethod
+	// Logger is used for all output of the repo, defaults to the global logger
+	Logger *zerolog.Logger
+	// Retry is applied to all remote operations, the zero value disables retries
+	Retry RetryPolicy
+	// Naming defines the names of new release branches and tags
+	Naming Naming
+	// TagMessage creates annotated tags with this message instead of lightweight tags
+	TagMessage string
+	// Scheme orders the branches and tags by their versions, nil uses semantic versioning
+	Scheme version.Scheme
+}
+
+// Tagger is the author of annotated tags
+var Tagger = object.Signature{Name: "git-releaser", Email: "git-releaser@localhost"}
+
+func (m *GitRepo) log() *zerolog.Logger {
+	if m.Logger == nil {
+		return &log.Logger
+	}
+	return m.Logger
 }
 
 func (m *GitRepo) GetStorer() storage.Storer {
 	return m.storer
 }
 
-//GetRemoteBranches get remote branches from GitHub using the repoURL
-func (m *GitRepo) GetAllRemoteBranchesAndTags(repoURL string) []*plumbing.Reference {
-	var stor *memory.Storage
+//GetRemoteBranches get remote branches from GitHub using the repoURL,
+// errors are returned as AuthError, NotFoundError or NetworkError if they can be classified.
+// The records of yanked versions below YankedPrefix are returned after the branches and tags.
+func (m *GitRepo) GetAllRemoteBranchesAndTags(ctx context.Context, repoURL string) ([]*plumbing.Reference, error) {
+	m.url = repoURL
 	if m.storer == nil {
-		stor = memory.NewStorage()
-		m.storer = stor
+		m.storer = memory.NewStorage()
 	}
+	// commits are stored under the hash of the remote reference, which is only possible with the memory storage
+	stor, _ := m.storer.(*memory.Storage)
 	if m.remote == nil {
 		rem := git.NewRemote(m.storer, &config.RemoteConfig{
 			Name: "origin",
@@ -60,104 +137,347 @@ func (m *GitRepo) GetAllRemoteBranchesAndTags(repoURL string) []*plumbing.Refere
 	}
 
 	// We can then use every Remote functions to retrieve wanted information
-	refs, err := m.remote.List(&git.ListOptions{Auth: m.Auth})
+	var refs []*plumbing.Reference
+	err := m.Retry.do(ctx, m.log(), "Listing remote references", func() error {
+		var err error
+		refs, err = m.remote.ListContext(ctx, &git.ListOptions{Auth: m.Auth, PeelingOption: git.AppendPeeled})
+		return err
+	}, nil)
 	if err != nil {
-		log.Err(err).Msg("")
+		return nil, classifyError(repoURL, err)
 	}
+	refs = peelTags(refs)
 
 	// Filters the references list and only keeps tags
 	var branches []*plumbing.Reference
 	var tags []*plumbing.Reference
+	var yanked []*plumbing.Reference
 
 	for _, ref := range refs {
+		yank := strings.HasPrefix(ref.Name().String(), YankedPrefix)
+		if !ref.Name().IsTag() && !ref.Name().IsBranch() && !yank {
+			continue
+		}
+		err := m.storer.SetReference(ref)
+		if err != nil {
+			m.log().Err(err).Msg("")
+			continue
+		}
+		// the commits of tags are stored as well, so a branch or tag can be pushed pointing to the commit of a tag
 		eo := m.storer.NewEncodedObject()
-		if ref.Name().IsTag() {
-			err := m.storer.SetReference(ref)
-			if err != nil {
-				log.Err(err).Msg("")
-				continue
-			}
-			tags = append(tags, ref)
-		} else if ref.Name().IsBranch() {
-			err := m.storer.SetReference(ref)
-			if err != nil {
-				log.Err(err).Msg("")
-				continue
-			}
-			commit := object.Commit{Hash: ref.Hash()}
-			err = commit.EncodeWithoutSignature(eo)
-			if err != nil {
-				log.Err(err).Msg("")
-				continue
-			}
+		commit := object.Commit{Hash: ref.Hash()}
+		err = commit.EncodeWithoutSignature(eo)
+		if err != nil {
+			m.log().Err(err).Msg("")
+			continue
+		}
+		if stor != nil {
 			stor.Objects[ref.Hash()] = eo
+		}
+		switch {
+		case yank:
+			yanked = append(yanked, ref)
+		case ref.Name().IsTag():
+			tags = append(tags, ref)
+		default:
 			branches = append(branches, ref)
 		}
 	}
 	branchesAndTags := append(tags, branches...)
-	sortBySemVer(branchesAndTags)
-	log.Info().Msgf("Remote branches and tags found: %v for repo %s", branchesAndTags, repoURL)
+	sortByVersion(branchesAndTags, m.Scheme, m.Naming.Granularity)
+	branchesAndTags = append(branchesAndTags, yanked...)
+	m.log().Info().Msgf("Remote branches and tags found: %v for repo %s", branchesAndTags, repoURL)
 
-	return branchesAndTags
+	return branchesAndTags, nil
 }
 
-func (m *GitRepo) CreateBranchAndTag(sourceBranch *plumbing.Reference, targetBranch, version string, createBranch, createTag bool) error {
+func (m *GitRepo) CreateBranchAndTag(ctx context.Context, sourceBranch *plumbing.Reference, targetBranch, version string, createBranch, createTag bool) error {
 	if createBranch {
 		// Create new branch
-		var branchName string
-		if targetBranch == "" {
-			branchName = version
-		} else {
-			branchName = fmt.Sprintf("%s/%s", targetBranch, version)
+		branchName, err := m.Naming.BranchName(targetBranch, version)
+		if err != nil {
+			m.log().Err(err).Msg("")
+			return err
 		}
 		ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branchName), sourceBranch.Hash())
 		// The created reference is saved in the storage.
-		err := m.storer.SetReference(ref)
+		err = m.storer.SetReference(ref)
 		if err != nil {
-			log.Err(err).Msg("")
+			m.log().Err(err).Msg("")
 			return err
 		}
-		refspec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName)
-		err = m.remote.Push(&git.PushOptions{RefSpecs: []config.RefSpec{config.RefSpec(refspec)}, Auth: m.Auth})
+		err = m.push(ctx, ref)
 		if err != nil {
-			log.Err(err).Msg("")
+			m.log().Err(err).Msg("")
 			return err
 		}
-		log.Info().Msgf("Successfully created branch %s/%s", targetBranch, version)
+		m.log().Info().Msgf("Successfully created branch %s", branchName)
 	}
 
 	if createTag {
-		ref := plumbing.NewHashReference(plumbing.NewTagReferenceName(version), sourceBranch.Hash())
-		err := m.storer.SetReference(ref)
+		tagName, err := m.Naming.TagName(targetBranch, version)
 		if err != nil {
-			log.Err(err).Msg("")
+			m.log().Err(err).Msg("")
 			return err
 		}
-		refspecs := fmt.Sprintf("refs/tags/%s:refs/tags/%s", version, version)
-		err = m.remote.Push(&git.PushOptions{RefSpecs: []config.RefSpec{config.RefSpec(refspecs)}, Auth: m.Auth})
-		if err != nil {
-			log.Err(err).Msg("")
+		if err := m.createTag(ctx, sourceBranch, tagName, m.TagMessage); err != nil {
+			m.log().Err(err).Msg("")
 			return err
 		}
-		log.Info().Msgf("Successfully created tag: %s", version)
+		m.log().Info().Msgf("Successfully created tag: %s", tagName)
 	}
 
 	return nil
 }
 
-func sortBySemVer(s []*plumbing.Reference) []*plumbing.Reference {
-	sort.SliceStable(s, func(i, j int) bool {
-		branchA := semver.Canonical(VersionRegex.FindString(s[i].Name().Short()))
-		branchB := semver.Canonical(VersionRegex.FindString(s[j].Name().Short()))
+// CreateTag pushes the tag name pointing to the commit of ref, it is annotated with message if set instead of TagMessage
+func (m *GitRepo) CreateTag(ctx context.Context, ref *plumbing.Reference, name, message string) error {
+	if err := m.createTag(ctx, ref, name, message); err != nil {
+		m.log().Err(err).Msg("")
+		return err
+	}
+	m.log().Info().Msgf("Successfully created tag: %s", name)
+	return nil
+}
 
-		switch semver.Compare(branchA, branchB) {
-		case -1:
-			return true
-		case 0:
-			return false
+func (m *GitRepo) createTag(ctx context.Context, ref *plumbing.Reference, name, message string) error {
+	hash := ref.Hash()
+	if message != "" {
+		var err error
+		if hash, err = newAnnotatedTag(m.storer, name, ref.Hash(), message); err != nil {
+			return err
+		}
+	}
+	tag := plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash)
+	if err := m.storer.SetReference(tag); err != nil {
+		return err
+	}
+	return m.push(ctx, tag)
+}
+
+// CreateReference pushes ref, which must point to the commit of a listed reference
+func (m *GitRepo) CreateReference(ctx context.Context, ref *plumbing.Reference) error {
+	if err := m.storer.SetReference(ref); err != nil {
+		m.log().Err(err).Msg("")
+		return err
+	}
+	if err := m.push(ctx, ref); err != nil {
+		m.log().Err(err).Msg("")
+		return err
+	}
+	m.log().Info().Msgf("Successfully created %s", ref.Name())
+	return nil
+}
+
+// DeleteReferences deletes the branches and tags names on the remote with a single push
+func (m *GitRepo) DeleteReferences(ctx context.Context, names ...plumbing.ReferenceName) error {
+	if len(names) == 0 {
+		return nil
+	}
+	refspecs := make([]config.RefSpec, len(names))
+	for i, name := range names {
+		refspecs[i] = config.RefSpec(":" + name.String())
+	}
+	err := m.Retry.do(ctx, m.log(), fmt.Sprintf("Deleting %d references", len(names)), func() error {
+		return m.remote.PushContext(ctx, &git.PushOptions{RefSpecs: refspecs, Auth: m.Auth})
+	}, func() bool {
+		return !m.remoteHasAnyReference(ctx, names)
+	})
+	if err != nil {
+		m.log().Err(err).Msg("")
+		return classifyError(m.url, err)
+	}
+	for _, name := range names {
+		_ = m.storer.RemoveReference(name)
+		m.log().Info().Msgf("Successfully deleted %s", name.Short())
+	}
+	return nil
+}
+
+// CommitTimes returns the commit dates of the commits refs point to by reference name. Listing the references doesn't download any commits,
+// so only these commits are fetched without their history into a separate storage with a single fetch.
+func (m *GitRepo) CommitTimes(ctx context.Context, refs ...*plumbing.Reference) (map[plumbing.ReferenceName]time.Time, error) {
+	times := make(map[plumbing.ReferenceName]time.Time, len(refs))
+	if len(refs) == 0 {
+		return times, nil
+	}
+	names := make([]plumbing.ReferenceName, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Name()
+	}
+	stor, err := m.fetchShallow(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		commit, err := object.GetCommit(stor, ref.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("could not read commit %s of %s: %w", ref.Hash(), ref.Name().Short(), err)
+		}
+		times[ref.Name()] = commit.Committer.When
+	}
+	return times, nil
+}
+
+// TagMessages returns the messages of the annotated tags names, lightweight tags have an empty message.
+// Like for CommitTimes, only the tags are fetched into a separate storage.
+func (m *GitRepo) TagMessages(ctx context.Context, names ...plumbing.ReferenceName) (map[plumbing.ReferenceName]string, error) {
+	messages := make(map[plumbing.ReferenceName]string, len(names))
+	if len(names) == 0 {
+		return messages, nil
+	}
+	stor, err := m.fetchShallow(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		ref, err := stor.Reference(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tag %s: %w", name.Short(), err)
+		}
+		tag, err := object.GetTag(stor, ref.Hash())
+		switch {
+		case errors.Is(err, plumbing.ErrObjectNotFound):
+			// a lightweight tag points to the commit directly
+			messages[name] = ""
+		case err != nil:
+			return nil, fmt.Errorf("could not read tag %s: %w", name.Short(), err)
 		default:
-			return false
+			messages[name] = tag.Message
+		}
+	}
+	return messages, nil
+}
+
+// ReadFile returns the content of the file path in the commit ref points to, a missing file returns object.ErrFileNotFound.
+// Like for CommitTimes, only this commit is fetched into a separate storage.
+func (m *GitRepo) ReadFile(ctx context.Context, ref *plumbing.Reference, path string) (string, error) {
+	stor, err := m.fetchShallow(ctx, ref.Name())
+	if err != nil {
+		return "", err
+	}
+	commit, err := object.GetCommit(stor, ref.Hash())
+	if err != nil {
+		return "", fmt.Errorf("could not read commit %s of %s: %w", ref.Hash(), ref.Name().Short(), err)
+	}
+	file, err := commit.File(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s of %s: %w", path, ref.Name().Short(), err)
+	}
+	return file.Contents()
+}
+
+// fetchShallow fetches the references names without their history into a new storage
+func (m *GitRepo) fetchShallow(ctx context.Context, names ...plumbing.ReferenceName) (storage.Storer, error) {
+	stor := memory.NewStorage()
+	rem := git.NewRemote(stor, &config.RemoteConfig{Name: "origin", URLs: []string{m.url}})
+	refspecs := make([]config.RefSpec, len(names))
+	for i, name := range names {
+		refspecs[i] = config.RefSpec(fmt.Sprintf("%s:%s", name, name))
+	}
+	description := fmt.Sprintf("Fetching %d references", len(names))
+	if len(names) == 1 {
+		description = fmt.Sprintf("Fetching %s", names[0].Short())
+	}
+	err := m.Retry.do(ctx, m.log(), description, func() error {
+		return rem.FetchContext(ctx, &git.FetchOptions{RefSpecs: refspecs, Depth: 1, Auth: m.Auth, Tags: git.NoTags})
+	}, nil)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, classifyError(m.url, err)
+	}
+	return stor, nil
+}
+
+// newAnnotatedTag stores a tag object pointing to the commit target and returns its hash
+func newAnnotatedTag(s storage.Storer, name string, target plumbing.Hash, message string) (plumbing.Hash, error) {
+	tagger := Tagger
+	tagger.When = time.Now()
+	// like git, the message ends with a newline
+	message = strings.TrimRight(message, "\n") + "\n"
+	tag := object.Tag{Name: name, Tagger: tagger, Message: message, TargetType: plumbing.CommitObject, Target: target}
+	eo := s.NewEncodedObject()
+	if err := tag.Encode(eo); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return s.SetEncodedObject(eo)
+}
+
+// peelTags replaces the hashes of annotated tags by the hashes of the tagged commits and drops the peeled references,
+// so tags can be compared with branches
+func peelTags(refs []*plumbing.Reference) []*plumbing.Reference {
+	peeled := map[plumbing.ReferenceName]plumbing.Hash{}
+	for _, ref := range refs {
+		if name := ref.Name().String(); strings.HasSuffix(name, "^{}") {
+			peeled[plumbing.ReferenceName(strings.TrimSuffix(name, "^{}"))] = ref.Hash()
+		}
+	}
+	result := make([]*plumbing.Reference, 0, len(refs))
+	for _, ref := range refs {
+		if strings.HasSuffix(ref.Name().String(), "^{}") {
+			continue
+		}
+		if hash, ok := peeled[ref.Name()]; ok {
+			ref = plumbing.NewHashReference(ref.Name(), hash)
+		}
+		result = append(result, ref)
+	}
+	return result
+}
+
+// push pushes ref to the remote, before a retry the remote is checked whether a former try already succeeded
+func (m *GitRepo) push(ctx context.Context, ref *plumbing.Reference) error {
+	refspec := config.RefSpec(fmt.Sprintf("%s:%s", ref.Name(), ref.Name()))
+	err := m.Retry.do(ctx, m.log(), fmt.Sprintf("Pushing %s", ref.Name().Short()), func() error {
+		return m.remote.PushContext(ctx, &git.PushOptions{RefSpecs: []config.RefSpec{refspec}, Auth: m.Auth})
+	}, func() bool {
+		return m.remoteHasReference(ctx, ref)
+	})
+	return classifyError(m.url, err)
+}
+
+// remoteHasReference checks if the remote already contains ref pointing to the same hash
+func (m *GitRepo) remoteHasReference(ctx context.Context, ref *plumbing.Reference) bool {
+	refs, err := m.remote.ListContext(ctx, &git.ListOptions{Auth: m.Auth})
+	if err != nil {
+		return false
+	}
+	for _, r := range refs {
+		if r.Name() == ref.Name() && r.Hash() == ref.Hash() {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHasAnyReference checks if the remote still contains one of names
+func (m *GitRepo) remoteHasAnyReference(ctx context.Context, names []plumbing.ReferenceName) bool {
+	refs, err := m.remote.ListContext(ctx, &git.ListOptions{Auth: m.Auth})
+	if err != nil {
+		return true
+	}
+	for _, r := range refs {
+		for _, name := range names {
+			if r.Name() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortByVersion sorts the references by the precedence of their versions, references without a version come first.
+// A nil scheme uses semantic versioning, the granularity defines the versions of line branches, see Granularity.RefVersion.
+func sortByVersion(s []*plumbing.Reference, scheme version.Scheme, granularity Granularity) []*plumbing.Reference {
+	if scheme == nil {
+		scheme = version.SemVer{}
+	}
+	sort.SliceStable(s, func(i, j int) bool {
+		a, aOK := granularity.RefVersion(s[i], scheme)
+		b, bOK := granularity.RefVersion(s[j], scheme)
+		if !aOK || !bOK {
+			return !aOK && bOK
 		}
+		return scheme.Compare(a, b) < 0
 	})
 	return s
 }
@@ -1,15 +1,25 @@
 package remote
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/fhopfensperger/git-releaser/pkg/gittest"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
 	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,13 +27,13 @@ type gitRepoMock struct {
 	mock.Mock
 }
 
-func (m *gitRepoMock) Push(options *git.PushOptions) error {
-	fmt.Println("Mocked Push() function")
+func (m *gitRepoMock) PushContext(ctx context.Context, options *git.PushOptions) error {
+	fmt.Println("Mocked PushContext() function")
 	return nil
 }
 
-func (m *gitRepoMock) List(o *git.ListOptions) (rfs []*plumbing.Reference, err error) {
-	fmt.Println("Mocked List() function")
+func (m *gitRepoMock) ListContext(ctx context.Context, o *git.ListOptions) (rfs []*plumbing.Reference, err error) {
+	fmt.Println("Mocked ListContext() function")
 	args := m.Called(o)
 	return args.Get(0).([]*plumbing.Reference), args.Error(1)
 }
@@ -55,9 +65,10 @@ func TestGitRepo_GetAllRemoteBranchesAndTags(t *testing.T) {
 
 	gitRepo := GitRepo{remote: gitRemoteRepo}
 
-	gitRemoteRepo.On("List", &git.ListOptions{}).Return(append(generateTagsPlumbReferences(), generateBranchPlumbReferences()...), nil)
+	gitRemoteRepo.On("ListContext", &git.ListOptions{PeelingOption: git.AppendPeeled}).Return(append(generateTagsPlumbReferences(), generateBranchPlumbReferences()...), nil)
 
-	refs := gitRepo.GetAllRemoteBranchesAndTags("https://github.com/just-a-repo-name")
+	refs, err := gitRepo.GetAllRemoteBranchesAndTags(context.Background(), "https://github.com/just-a-repo-name")
+	assert.NoError(t, err)
 	gitRemoteRepo.AssertExpectations(t)
 
 	sortedRefs := []*plumbing.Reference{
@@ -72,9 +83,10 @@ func TestGitRepo_GetAllRemoteBranchesAndTags_TagsOnly(t *testing.T) {
 
 	gitRepo := GitRepo{remote: gitRemoteRepo}
 
-	gitRemoteRepo.On("List", &git.ListOptions{}).Return(append(generateTagsPlumbReferences(), main, test, dev), nil)
+	gitRemoteRepo.On("ListContext", &git.ListOptions{PeelingOption: git.AppendPeeled}).Return(append(generateTagsPlumbReferences(), main, test, dev), nil)
 
-	refs := gitRepo.GetAllRemoteBranchesAndTags("https://github.com/just-a-repo-name")
+	refs, err := gitRepo.GetAllRemoteBranchesAndTags(context.Background(), "https://github.com/just-a-repo-name")
+	assert.NoError(t, err)
 	gitRemoteRepo.AssertExpectations(t)
 
 	sortedRefs := []*plumbing.Reference{
@@ -89,9 +101,10 @@ func TestGitRepo_GetAllRemoteBranchesAndTags_BranchesOnly(t *testing.T) {
 
 	gitRepo := GitRepo{remote: gitRemoteRepo}
 
-	gitRemoteRepo.On("List", &git.ListOptions{}).Return(append(generateTagsPlumbReferences(), main, test, dev), nil)
+	gitRemoteRepo.On("ListContext", &git.ListOptions{PeelingOption: git.AppendPeeled}).Return(append(generateTagsPlumbReferences(), main, test, dev), nil)
 
-	refs := gitRepo.GetAllRemoteBranchesAndTags("https://github.com/just-a-repo-name")
+	refs, err := gitRepo.GetAllRemoteBranchesAndTags(context.Background(), "https://github.com/just-a-repo-name")
+	assert.NoError(t, err)
 	gitRemoteRepo.AssertExpectations(t)
 
 	sortedRefs := []*plumbing.Reference{
@@ -105,7 +118,7 @@ func TestGitRepo_CreateBranchAndTag(t *testing.T) {
 	gitRemoteRepo := new(gitRepoMock)
 	m := GitRepo{remote: gitRemoteRepo, storer: memory.NewStorage()}
 
-	gitRemoteRepo.On("Push", &git.PushOptions{}).Return(nil)
+	gitRemoteRepo.On("PushContext", &git.PushOptions{}).Return(nil)
 
 	type args struct {
 		sourceBranch *plumbing.Reference
@@ -127,14 +140,80 @@ func TestGitRepo_CreateBranchAndTag(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := m.CreateBranchAndTag(tt.args.sourceBranch, tt.args.targetBranch, tt.args.version, tt.args.createBranch, tt.args.createTag); (err != nil) != tt.wantErr {
+			if err := m.CreateBranchAndTag(context.Background(), tt.args.sourceBranch, tt.args.targetBranch, tt.args.version, tt.args.createBranch, tt.args.createTag); (err != nil) != tt.wantErr {
 				t.Errorf("CreateBranchAndTag() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_sortBySemVer(t *testing.T) {
+func TestGitRepo_TagMessages(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.AnnotatedTag("v1.0.0-rc.1", "first candidate\n"), gittest.Tag("v1.0.0-rc.2"))
+	m := GitRepo{}
+	_, err := m.GetAllRemoteBranchesAndTags(context.Background(), r.URL())
+	assert.NoError(t, err)
+
+	messages, err := m.TagMessages(context.Background(), plumbing.NewTagReferenceName("v1.0.0-rc.1"), plumbing.NewTagReferenceName("v1.0.0-rc.2"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[plumbing.ReferenceName]string{"refs/tags/v1.0.0-rc.1": "first candidate\n", "refs/tags/v1.0.0-rc.2": ""}, messages)
+
+	_, err = m.TagMessages(context.Background(), plumbing.NewTagReferenceName("v2.0.0"))
+	assert.Error(t, err)
+}
+
+func TestGitRepo_CommitTimes(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.Branch("release/v1.0.0"), gittest.Commit("fix"), gittest.Branch("release/v1.0.1"))
+	m := GitRepo{}
+	refs, err := m.GetAllRemoteBranchesAndTags(context.Background(), r.URL())
+	require.NoError(t, err)
+	require.Len(t, refs, 3)
+
+	times, err := m.CommitTimes(context.Background(), refs[1], refs[2])
+
+	require.NoError(t, err)
+	assert.Len(t, times, 2)
+	assert.Equal(t, time.Minute, times["refs/heads/release/v1.0.1"].Sub(times["refs/heads/release/v1.0.0"]))
+	assert.Equal(t, 2020, times["refs/heads/release/v1.0.0"].Year())
+
+	times, err = m.CommitTimes(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, times)
+}
+
+func TestGitRepo_ReadFile(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.CommitFile("initial", "go.mod", "module github.com/acme/api\n"), gittest.Branch("main"), gittest.AnnotatedTag("v1.0.0", "first release"))
+	m := GitRepo{}
+	refs, err := m.GetAllRemoteBranchesAndTags(context.Background(), r.URL())
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	tag := refs[1]
+
+	content, err := m.ReadFile(context.Background(), tag, "go.mod")
+	assert.NoError(t, err)
+	assert.Equal(t, "module github.com/acme/api\n", content)
+	_, err = m.ReadFile(context.Background(), tag, "go.sum")
+	assert.ErrorIs(t, err, object.ErrFileNotFound)
+}
+
+func TestGitRepo_CreateReference(t *testing.T) {
+	r := gittest.NewRepo(t, gittest.Commit("initial"), gittest.Branch("main"), gittest.AnnotatedTag("v1.0.0", "first release"))
+	m := GitRepo{}
+	refs, err := m.GetAllRemoteBranchesAndTags(context.Background(), r.URL())
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	tag := refs[1]
+
+	// the yank is recorded at the commit of the tag and listed after the branches and tags
+	yanked := plumbing.NewHashReference(YankedReferenceName("v1.0.0"), tag.Hash())
+	require.NoError(t, m.CreateReference(context.Background(), yanked))
+	assert.Equal(t, r.Hash("main"), r.Hash("refs/yanked/v1.0.0"))
+	refs, err = m.GetAllRemoteBranchesAndTags(context.Background(), r.URL())
+	require.NoError(t, err)
+	assert.Equal(t, yanked, refs[len(refs)-1])
+}
+
+func Test_sortByVersion(t *testing.T) {
 
 	tests := []struct {
 		name string
@@ -164,16 +243,149 @@ func Test_sortBySemVer(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := sortBySemVer(tt.s); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("sortBySemVer() = %v, want %v", got, tt.want)
+			if got := sortByVersion(tt.s, nil, PerPatch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortByVersion() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_sortByVersion_precedence(t *testing.T) {
+	ref := func(name string) *plumbing.Reference {
+		return plumbing.NewHashReference(plumbing.NewTagReferenceName(name), plumbing.ZeroHash)
+	}
+	v10, v2, rc, beta, migration := ref("v10.0.0"), ref("v2.0.0"), ref("v2.0.0-rc.1"), ref("v2.0.0-beta.11"), ref("v2.0-migration")
+
+	got := sortByVersion([]*plumbing.Reference{v10, v2, rc, migration, beta}, version.SemVer{}, PerPatch)
+
+	assert.Equal(t, []*plumbing.Reference{migration, beta, rc, v2, v10}, got)
+}
+
+func Test_sortByVersion_calver(t *testing.T) {
+	ref := func(name string) *plumbing.Reference {
+		return plumbing.NewHashReference(plumbing.NewTagReferenceName(name), plumbing.ZeroHash)
+	}
+	oct10, oct3, sep, semver := ref("2026.10.10"), ref("2026.10.3"), ref("2026.09.12"), ref("v1.2.3")
+	calver, err := version.NewCalVer("YYYY.0M.MICRO", nil)
+	assert.NoError(t, err)
+
+	got := sortByVersion([]*plumbing.Reference{oct10, semver, oct3, sep}, calver, PerPatch)
+
+	assert.Equal(t, []*plumbing.Reference{semver, sep, oct3, oct10}, got)
+}
+
 func TestGitRepo_GetStorer(t *testing.T) {
 	gitRemoteRepo := new(gitRepoMock)
 	stor := memory.NewStorage()
 	m := GitRepo{remote: gitRemoteRepo, storer: stor}
 	assert.Equal(t, stor, m.GetStorer())
 }
+
+func TestNaming(t *testing.T) {
+	n := Naming{}
+	branch, err := n.BranchName("release", "v1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "release/v1.2.3", branch)
+	branch, err = n.BranchName("", "v1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3", branch)
+	tag, err := n.TagName("release", "v1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3", tag)
+
+	n = Naming{BranchTemplate: "{{.Target}}-{{.Number}}", TagTemplate: "app-{{.Version}}"}
+	assert.NoError(t, n.Validate())
+	branch, _ = n.BranchName("release", "v1.2.3")
+	assert.Equal(t, "release-1.2.3", branch)
+	tag, _ = n.TagName("release", "v1.2.3")
+	assert.Equal(t, "app-v1.2.3", tag)
+
+	n = Naming{TagTemplate: "{{.Unknown}"}
+	assert.Error(t, n.Validate())
+	_, err = n.TagName("release", "v1.2.3")
+	assert.Error(t, err)
+}
+
+func TestNaming_granularity(t *testing.T) {
+	n := Naming{Granularity: PerMinor}
+	branch, err := n.BranchName("release", "v1.4.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "release/v1.4", branch)
+	tag, _ := n.TagName("release", "v1.4.2")
+	assert.Equal(t, "v1.4.2", tag)
+
+	n = Naming{BranchTemplate: "{{.Target}}-{{.Number}}", Granularity: PerMajor}
+	branch, _ = n.BranchName("support", "v2.1.0")
+	assert.Equal(t, "support-2", branch)
+
+	_, err = n.BranchName("release", "2026.10")
+	assert.Error(t, err)
+
+	for s, want := range map[string]Granularity{"": PerPatch, "patch": PerPatch, "Minor": PerMinor, "major": PerMajor} {
+		g, err := ParseGranularity(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, g, s)
+	}
+	_, err = ParseGranularity("daily")
+	assert.ErrorContains(t, err, "daily")
+}
+
+func Test_sortByVersion_line_branches(t *testing.T) {
+	line := plumbing.NewHashReference(plumbing.NewBranchReferenceName("release/v1.4"), plumbing.NewHash("a1"))
+	patch := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.4.2"), plumbing.NewHash("a2"))
+	old := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.3.9"), plumbing.NewHash("a3"))
+	floating := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1"), plumbing.NewHash("a4"))
+
+	got := sortByVersion([]*plumbing.Reference{patch, line, old, floating}, nil, PerMinor)
+	assert.Equal(t, []*plumbing.Reference{floating, old, line, patch}, got, "only branches can be lines")
+
+	got = sortByVersion([]*plumbing.Reference{patch, line, old}, nil, PerPatch)
+	assert.Equal(t, []*plumbing.Reference{line, old, patch}, got)
+}
+
+func TestGitRepo_GetAllRemoteBranchesAndTags_Error(t *testing.T) {
+	gitRemoteRepo := new(gitRepoMock)
+	gitRepo := GitRepo{remote: gitRemoteRepo}
+	gitRemoteRepo.On("ListContext", &git.ListOptions{PeelingOption: git.AppendPeeled}).Return([]*plumbing.Reference(nil), transport.ErrAuthenticationRequired)
+
+	refs, err := gitRepo.GetAllRemoteBranchesAndTags(context.Background(), "https://github.com/just-a-repo-name")
+
+	assert.Nil(t, refs)
+	var authErr *AuthError
+	assert.ErrorAs(t, err, &authErr)
+	assert.Equal(t, "https://github.com/just-a-repo-name", authErr.URL)
+	assert.ErrorIs(t, err, transport.ErrAuthenticationRequired)
+}
+
+func Test_classifyError(t *testing.T) {
+	var authErr *AuthError
+	var notFoundErr *NotFoundError
+	var networkErr *NetworkError
+
+	assert.Nil(t, classifyError("url", nil))
+	assert.ErrorAs(t, classifyError("url", transport.ErrAuthorizationFailed), &authErr)
+	assert.ErrorAs(t, classifyError("url", errors.New("ssh: handshake failed: ssh: unable to authenticate")), &authErr)
+	assert.ErrorAs(t, classifyError("url", transport.ErrRepositoryNotFound), &notFoundErr)
+	assert.ErrorAs(t, classifyError("url", io.ErrUnexpectedEOF), &networkErr)
+	assert.ErrorAs(t, classifyError("url", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), &networkErr)
+
+	other := errors.New("non-fast-forward update")
+	assert.Equal(t, other, classifyError("url", other))
+}
+
+func Test_peelTags(t *testing.T) {
+	annotated := plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("a1"))
+	lightweight := plumbing.NewHashReference("refs/tags/v1.1.0", plumbing.NewHash("c3"))
+	refs := []*plumbing.Reference{
+		main,
+		annotated,
+		plumbing.NewHashReference("refs/tags/v1.0.0^{}", plumbing.NewHash("b2")),
+		lightweight,
+	}
+
+	assert.Equal(t, []*plumbing.Reference{
+		main,
+		plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("b2")),
+		lightweight,
+	}, peelTags(refs))
+}
@@ -0,0 +1,143 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/rs/zerolog"
+)
+
+// RetryPolicy defines how often and with which delay failed remote operations are retried.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// Attempts is the total number of tries, values lower than 2 disable retries
+	Attempts int
+	// InitialBackoff is the delay before the first retry, it is doubled for every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two tries, 0 means no limit
+	MaxBackoff time.Duration
+	// Jitter is the fraction (0 - 1) of the delay which is randomly added or subtracted
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries twice starting with a delay of one second
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second, Jitter: 0.2}
+
+// permanentErrors are never retried, as another try will end up with the same result
+var permanentErrors = []error{
+	transport.ErrAuthenticationRequired,
+	transport.ErrAuthorizationFailed,
+	transport.ErrRepositoryNotFound,
+	transport.ErrEmptyRemoteRepository,
+	transport.ErrInvalidAuthMethod,
+	git.ErrNonFastForwardUpdate,
+	git.ErrForceNeeded,
+	git.NoErrAlreadyUpToDate,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// transientMessages are parts of error messages of dropped connections, which are not exposed as typed errors
+var transientMessages = []string{
+	"connection reset by peer",
+	"connection refused",
+	"broken pipe",
+	"unexpected eof",
+	"i/o timeout",
+	"handshake failed: eof",
+	"tls handshake timeout",
+	"no route to host",
+	"temporary failure in name resolution",
+}
+
+// IsTransient reports whether err is caused by a temporary network or server problem, so the operation can be retried
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, p := range permanentErrors {
+		if errors.Is(err, p) {
+			return false
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "non-fast-forward") || strings.Contains(msg, "unable to authenticate") {
+		return false
+	}
+
+	var httpErr *http.Err
+	if errors.As(err, &httpErr) && httpErr.Response != nil {
+		code := httpErr.StatusCode()
+		return code >= 500 || code == 408 || code == 429
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry, retry starts with 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff == 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// do calls fn until it succeeds, returns a permanent error, the attempts are exhausted or ctx is done.
+// If ctx is done while waiting for a retry, the returned error wraps ctx.Err() and the last error.
+// beforeRetry is called before every retry, if it returns true the operation is considered successful.
+func (p RetryPolicy) do(ctx context.Context, logger *zerolog.Logger, operation string, fn func() error, beforeRetry func() bool) error {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= attempts || !IsTransient(err) {
+			return err
+		}
+		delay := p.backoff(attempt)
+		logger.Warn().Err(err).Msgf("%s failed (attempt %d of %d), retrying in %s", operation, attempt, attempts, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s aborted: %w, last error: %w", operation, ctx.Err(), err)
+		case <-timer.C:
+		}
+		if beforeRetry != nil && beforeRetry() {
+			logger.Info().Msgf("%s already applied on the remote, not retrying", operation)
+			return nil
+		}
+	}
+}
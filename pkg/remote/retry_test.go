@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTransient(t *testing.T) {
+	httpErr := func(code int) error {
+		return &http.Err{Response: &nethttp.Response{StatusCode: code, Request: &nethttp.Request{URL: &url.URL{}}}}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"auth required", transport.ErrAuthenticationRequired, false},
+		{"auth failed wrapped", fmt.Errorf("list: %w", transport.ErrAuthorizationFailed), false},
+		{"not found", transport.ErrRepositoryNotFound, false},
+		{"non fast forward", errors.New("non-fast-forward update: refs/heads/release/v1.0.0"), false},
+		{"ssh auth", errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey]"), false},
+		{"cancelled", context.Canceled, false},
+		{"bad gateway", httpErr(502), true},
+		{"too many requests", httpErr(429), true},
+		{"forbidden", httpErr(403), false},
+		{"eof", io.EOF, true},
+		{"unexpected eof wrapped", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"connection reset", errors.New("read tcp 10.0.0.1:22: read: connection reset by peer"), true},
+		{"unknown", errors.New("something else"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 5*time.Second, p.backoff(4))
+	assert.Equal(t, 5*time.Second, p.backoff(60))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, 1500*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_do(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}
+
+	calls := 0
+	err := p.do(context.Background(), &log.Logger, "test", func() error {
+		calls++
+		return io.EOF
+	}, nil)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = p.do(context.Background(), &log.Logger, "test", func() error {
+		calls++
+		return transport.ErrAuthenticationRequired
+	}, nil)
+	assert.ErrorIs(t, err, transport.ErrAuthenticationRequired)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	err = p.do(context.Background(), &log.Logger, "test", func() error {
+		calls++
+		if calls < 2 {
+			return io.EOF
+		}
+		return nil
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = p.do(context.Background(), &log.Logger, "test", func() error {
+		calls++
+		return io.EOF
+	}, func() bool { return true })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_do_zero_value(t *testing.T) {
+	calls := 0
+	err := RetryPolicy{}.do(context.Background(), &log.Logger, "test", func() error {
+		calls++
+		return io.EOF
+	}, nil)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_do_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := RetryPolicy{Attempts: 5, InitialBackoff: time.Hour}.do(ctx, &log.Logger, "test", func() error {
+		calls++
+		return io.EOF
+	}, nil)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_do_deadline_exceeded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := RetryPolicy{Attempts: 5, InitialBackoff: time.Hour}.do(ctx, &log.Logger, "test", func() error {
+		return io.EOF
+	}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, IsTransient(err))
+}
+
+// flakyRemote fails the first push with a dropped connection, although the reference has been updated on the remote
+type flakyRemote struct {
+	refs   []*plumbing.Reference
+	pushes int
+}
+
+func (f *flakyRemote) ListContext(ctx context.Context, o *git.ListOptions) ([]*plumbing.Reference, error) {
+	return f.refs, nil
+}
+
+func (f *flakyRemote) PushContext(ctx context.Context, o *git.PushOptions) error {
+	f.pushes++
+	name := plumbing.ReferenceName(o.RefSpecs[0].Src())
+	f.refs = append(f.refs, plumbing.NewHashReference(name, main.Hash()))
+	return io.ErrUnexpectedEOF
+}
+
+func TestGitRepo_CreateBranchAndTag_retry_is_idempotent(t *testing.T) {
+	rem := &flakyRemote{}
+	m := GitRepo{remote: rem, storer: memory.NewStorage(), Retry: RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}}
+
+	err := m.CreateBranchAndTag(context.Background(), main, "release", "v1.0.1", true, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rem.pushes)
+}
+
+// flakyDeleteRemote deletes all references with the first push, but fails it with a dropped connection
+type flakyDeleteRemote struct {
+	flakyRemote
+}
+
+func (f *flakyDeleteRemote) PushContext(ctx context.Context, o *git.PushOptions) error {
+	f.pushes++
+	f.refs = nil
+	return io.ErrUnexpectedEOF
+}
+
+func TestGitRepo_DeleteReferences_retry_is_idempotent(t *testing.T) {
+	tag := plumbing.NewHashReference(plumbing.NewTagReferenceName("nightly/v1.0.1-dev.20261017+gaa48656"), main.Hash())
+	rem := &flakyDeleteRemote{flakyRemote{refs: []*plumbing.Reference{tag}}}
+	stor := memory.NewStorage()
+	assert.NoError(t, stor.SetReference(tag))
+	m := GitRepo{remote: rem, storer: stor, Retry: RetryPolicy{Attempts: 3, InitialBackoff: time.Millisecond}}
+
+	err := m.DeleteReferences(context.Background(), tag.Name())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rem.pushes)
+	_, err = stor.Reference(tag.Name())
+	assert.ErrorIs(t, err, plumbing.ErrReferenceNotFound)
+}
@@ -1,17 +1,19 @@
 package repo
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 
 	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/mod/semver"
 )
 
 const (
@@ -20,6 +22,27 @@ const (
 	PATCH = iota // PATCH == 2
 )
 
+var (
+	// ErrInvalidVersion is returned by ReleaseVersion for versions which aren't valid SemVer like v3.0.0
+	ErrInvalidVersion = version.ErrInvalid
+	// ErrVersionNotGreater is returned by ReleaseVersion for versions which aren't greater than the latest release
+	ErrVersionNotGreater = errors.New("version is not greater than the latest release")
+	// ErrVersionExists is returned by ReleaseVersion for versions which already exist as tag or release branch
+	ErrVersionExists = errors.New("version already exists")
+	// ErrVersionYanked is returned by ReleaseVersion for versions which have been yanked, see Yank
+	ErrVersionYanked = errors.New("version has been yanked")
+	// ErrSnapshotScheme is returned by SnapshotVersion for versioning schemes without snapshots
+	ErrSnapshotScheme = errors.New("snapshots require semantic versioning")
+	// ErrHotfixScheme is returned by SetHotfixLine for versioning schemes without release lines
+	ErrHotfixScheme = errors.New("hotfixes require semantic versioning")
+	// ErrHotfixBump is returned by NextReleaseVersion for MAJOR and MINOR releases of a hotfix line
+	ErrHotfixBump = errors.New("hotfixes can't increment the version numbers of their release line")
+	// ErrOutsideLine is returned for versions and source branches which don't belong to the hotfix line
+	ErrOutsideLine = errors.New("version is outside of the hotfix line")
+	// ErrLineNotReleased is returned by NextReleaseVersion if the hotfix line has no release yet
+	ErrLineNotReleased = errors.New("hotfix line has no release")
+)
+
 type Repo struct {
 	remoteUrl     string
 	allReferences []*plumbing.Reference
@@ -33,20 +56,112 @@ type Repo struct {
 	nextReleaseVersion     string
 	remoteBranch           remote.CreateBranchAndTager
 	branchFilter           string
+	scheme                 version.Scheme
+	// skippedVersions are the yanked versions skipped by NextReleaseVersion
+	skippedVersions []string
+	// line restricts the release to hotfixes of a release line, nil allows all versions
+	line *version.Line
+	// granularity defines the versions of line branches like release/v1.4
+	granularity remote.Granularity
+	// tagMessage annotates snapshot tags
+	tagMessage string
+	logger     *zerolog.Logger
+}
+
+type options struct {
+	auth   transport.AuthMethod
+	logger *zerolog.Logger
+	retry  remote.RetryPolicy
+	naming remote.Naming
+	remote remote.CreateBranchAndTager
+	// tagMessage creates annotated tags
+	tagMessage string
+	scheme     version.Scheme
+}
+
+// Option configures a Repo created by New
+type Option func(*options)
+
+// WithAuth sets the credentials of all remote operations, without it the default ssh agent is used
+func WithAuth(auth transport.AuthMethod) Option {
+	return func(o *options) { o.auth = auth }
+}
+
+// WithLogger sets the logger of the repo, without it the global logger is used
+func WithLogger(logger *zerolog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithRetry sets the retry policy of all remote operations, without it remote operations aren't retried
+func WithRetry(retry remote.RetryPolicy) Option {
+	return func(o *options) { o.retry = retry }
+}
+
+// WithNaming sets the templates of new release branches and tags
+func WithNaming(naming remote.Naming) Option {
+	return func(o *options) { o.naming = naming }
+}
+
+// WithTagMessage creates annotated tags with message instead of lightweight tags
+func WithTagMessage(message string) Option {
+	return func(o *options) { o.tagMessage = message }
+}
+
+// WithScheme sets the versioning scheme, without it semantic versioning is used
+func WithScheme(scheme version.Scheme) Option {
+	return func(o *options) { o.scheme = scheme }
+}
+
+// WithRemote replaces the git remote, e.g. by a fake in tests. WithAuth, WithRetry, WithNaming and WithTagMessage don't apply to it,
+// the remote has to order the references by WithScheme
+func WithRemote(rem remote.CreateBranchAndTager) Option {
+	return func(o *options) { o.remote = rem }
 }
 
-func New(remoteUrl string, auth transport.AuthMethod) *Repo {
+// New lists all branches and tags of remoteUrl. Remote errors can be checked with errors.As
+// for remote.AuthError, remote.NotFoundError and remote.NetworkError
+func New(ctx context.Context, remoteUrl string, opts ...Option) (*Repo, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
 	r := Repo{}
 	r.remoteUrl = remoteUrl
-	r.remoteBranch = &remote.GitRepo{Auth: auth}
-	r.allReferences = r.remoteBranch.GetAllRemoteBranchesAndTags(remoteUrl)
-	return &r
+	r.logger = o.logger
+	r.scheme = o.scheme
+	r.granularity = o.naming.Granularity
+	r.tagMessage = o.tagMessage
+	r.remoteBranch = o.remote
+	if r.remoteBranch == nil {
+		r.remoteBranch = &remote.GitRepo{Auth: o.auth, Logger: o.logger, Retry: o.retry, Naming: o.naming, TagMessage: o.tagMessage, Scheme: o.scheme}
+	}
+	refs, err := r.remoteBranch.GetAllRemoteBranchesAndTags(ctx, remoteUrl)
+	if err != nil {
+		return nil, err
+	}
+	r.allReferences = refs
+	return &r, nil
+}
+
+func (r *Repo) versionScheme() version.Scheme {
+	if r.scheme == nil {
+		return version.SemVer{}
+	}
+	return r.scheme
+}
+
+func (r *Repo) log() *zerolog.Logger {
+	if r.logger == nil {
+		return &log.Logger
+	}
+	return r.logger
 }
 
 func (r *Repo) GetVersionBranches(branchFilter string) []*plumbing.Reference {
 	r.branchFilter = branchFilter
+	r.versionBranches = nil
 	for _, b := range r.allReferences {
-		if b.Name().IsBranch() && strings.Contains(b.Name().Short(), branchFilter) && remote.VersionRegex.FindString(b.Name().Short()) != "" {
+		if r.refVersion(b) != "" && b.Name().IsBranch() && strings.Contains(b.Name().Short(), branchFilter) && r.inLine(b) && !r.isHotfixSource(b) {
 			r.versionBranches = append(r.versionBranches, b)
 		}
 	}
@@ -54,8 +169,9 @@ func (r *Repo) GetVersionBranches(branchFilter string) []*plumbing.Reference {
 }
 
 func (r *Repo) GetVersionTags() []*plumbing.Reference {
+	r.versionTags = nil
 	for _, b := range r.allReferences {
-		if b.Name().IsTag() && remote.VersionRegex.FindString(b.Name().Short()) != "" {
+		if r.refVersion(b) != "" && b.Name().IsTag() && r.inLine(b) {
 			r.versionTags = append(r.versionTags, b)
 		}
 	}
@@ -63,33 +179,79 @@ func (r *Repo) GetVersionTags() []*plumbing.Reference {
 }
 
 func (r *Repo) GetLatestVersionReference() *plumbing.Reference {
-	var latestBranchVersion, latestTagVersion string
 	var latestBranch, latestTag *plumbing.Reference
-
 	if len(r.versionBranches) > 0 {
 		latestBranch = r.versionBranches[len(r.versionBranches)-1]
-		latestBranchVersion = remote.VersionRegex.FindString(latestBranch.Name().Short())
 	}
 	if len(r.versionTags) > 0 {
 		latestTag = r.versionTags[len(r.versionTags)-1]
-		latestTagVersion = remote.VersionRegex.FindString(latestTag.Name().Short())
 	}
 
-	if latestBranch == nil && latestTag == nil {
+	switch {
+	case latestBranch == nil && latestTag == nil:
+		r.latestVersionReference = nil
 		return nil
-	}
-
-	switch semver.Compare(latestBranchVersion, latestTagVersion) {
-	case -1:
+	case latestBranch == nil:
 		r.latestVersionReference = latestTag
-		return latestTag
-	case 0:
+	case latestTag == nil:
 		r.latestVersionReference = latestBranch
-		return latestBranch
+	case r.versionScheme().Compare(r.refVersion(latestBranch), r.refVersion(latestTag)) < 0:
+		r.latestVersionReference = latestTag
 	default:
+		// a branch and a tag of the same version are both released, the branch is preferred
 		r.latestVersionReference = latestBranch
-		return latestBranch
 	}
+	return r.latestVersionReference
+}
+
+// refVersion returns the version of a reference, empty if it has none.
+// Snapshots like v1.4.0-dev.20261017+g1a2b3c4 aren't released and have no version either.
+func (r *Repo) refVersion(ref *plumbing.Reference) string {
+	v, ok := r.granularity.RefVersion(ref, r.versionScheme())
+	if !ok || isSnapshot(v) {
+		return ""
+	}
+	return v
+}
+
+// SetHotfixLine restricts the release to hotfixes of line, e.g. v1.3 while main is at v1.5 already.
+// GetVersionBranches and GetVersionTags only return versions of the line, NextReleaseVersion only increments the numbers after the line
+// and ReleaseVersion only accepts versions of the line. A source branch of the line like release/v1.3.2 is the base of the hotfix,
+// it isn't a release itself. GetSourceBranch has to be called first.
+func (r *Repo) SetHotfixLine(line version.Line) error {
+	if _, ok := r.versionScheme().(version.SemVer); !ok {
+		return ErrHotfixScheme
+	}
+	if r.sourceBranch != nil {
+		if v, err := version.Parse(r.refVersion(r.sourceBranch)); err == nil && !line.Contains(v) {
+			return fmt.Errorf("%w: source branch %s doesn't belong to line %s", ErrOutsideLine, r.sourceBranch.Name().Short(), line)
+		}
+	}
+	r.line = &line
+	return nil
+}
+
+// inLine reports whether ref belongs to the hotfix line, without a line all references belong to it
+func (r *Repo) inLine(ref *plumbing.Reference) bool {
+	if r.line == nil {
+		return true
+	}
+	v, err := version.Parse(r.refVersion(ref))
+	return err == nil && r.line.Contains(v)
+}
+
+// isHotfixSource reports whether ref is the source branch of a hotfix
+func (r *Repo) isHotfixSource(ref *plumbing.Reference) bool {
+	return r.line != nil && r.sourceBranch != nil && ref.Name() == r.sourceBranch.Name()
+}
+
+func isSnapshot(v string) bool {
+	parsed, err := version.Parse(v)
+	if err != nil {
+		return false
+	}
+	_, ok := version.SnapshotDate(parsed)
+	return ok
 }
 
 func (r *Repo) GetSourceBranch(name string) *plumbing.Reference {
@@ -102,77 +264,302 @@ func (r *Repo) GetSourceBranch(name string) *plumbing.Reference {
 	return nil
 }
 
+// NextReleaseVersion increments the nextVersion number of the latest version reference,
+// without a latest version the first version of the scheme is returned. Yanked versions are skipped.
 func (r *Repo) NextReleaseVersion(nextVersion int) (string, error) {
-	if r.latestVersionReference == nil {
-		r.nextReleaseVersion = fallBackVersion(nextVersion)
-		return r.nextReleaseVersion, nil
+	var latest string
+	if r.latestVersionReference != nil {
+		latest = r.refVersion(r.latestVersionReference)
+	}
+	part := version.Part(nextVersion)
+	if r.line != nil {
+		if part <= r.line.Part() || part > version.Patch {
+			return "", fmt.Errorf("%w %s", ErrHotfixBump, r.line)
+		}
+		if latest == "" && r.sourceBranch != nil {
+			// without a release in the line, the hotfix is based on the version of the source branch
+			latest = r.refVersion(r.sourceBranch)
+		}
+		if latest == "" {
+			return "", fmt.Errorf("%w: %s", ErrLineNotReleased, r.line)
+		}
+	}
+	if part < version.Major || part > version.Patch {
+		// unknown numbers start with a new minor version
+		latest, part = "", version.Minor
 	}
+	next, err := r.versionScheme().Next(latest, part)
+	r.skippedVersions = nil
+	for err == nil && r.IsYanked(next) {
+		r.log().Info().Msgf("Skipping yanked version %s", next)
+		r.skippedVersions = append(r.skippedVersions, next)
+		next, err = r.versionScheme().Next(next, part)
+	}
+	if err != nil {
+		return "", err
+	}
+	r.nextReleaseVersion = next
+	return next, nil
+}
 
-	semLatestVersion := semver.Canonical(remote.VersionRegex.FindString(r.latestVersionReference.Name().Short()))
-	latestVersionSlice := strings.Split(semLatestVersion, ".")
+// SkippedVersions returns the yanked versions skipped by the last call of NextReleaseVersion, oldest first
+func (r *Repo) SkippedVersions() []string {
+	return r.skippedVersions
+}
 
-	if len(latestVersionSlice) == 1 {
-		r.nextReleaseVersion = fallBackVersion(nextVersion)
-		return r.nextReleaseVersion, nil
+// SnapshotVersion returns the snapshot version of the source branch like v1.4.0-dev.20261017+g1a2b3c4,
+// which is the version of NextReleaseVersion with the commit date and hash of the source branch.
+// GetSourceBranch and GetLatestVersionReference have to be called first.
+func (r *Repo) SnapshotVersion(ctx context.Context, nextVersion int) (string, error) {
+	if _, ok := r.versionScheme().(version.SemVer); !ok {
+		return "", ErrSnapshotScheme
+	}
+	next, err := r.NextReleaseVersion(nextVersion)
+	if err != nil {
+		return "", err
 	}
+	v, err := version.Parse(next)
+	if err != nil {
+		return "", err
+	}
+	date, err := r.CommitTime(ctx, r.sourceBranch)
+	if err != nil {
+		return "", err
+	}
+	snapshot := version.Snapshot(v, date, r.sourceBranch.Hash().String()).String()
+	r.nextReleaseVersion = snapshot
+	return snapshot, nil
+}
 
-	var versionNumber = regexp.MustCompile(`\d`)
+// SnapshotTags returns the snapshot tags below prefix, e.g. nightly/, whose commit date is before the given time
+func (r *Repo) SnapshotTags(prefix string, before time.Time) []*plumbing.Reference {
+	var tags []*plumbing.Reference
+	for _, ref := range r.allReferences {
+		name, ok := strings.CutPrefix(ref.Name().Short(), prefix)
+		if !ref.Name().IsTag() || !ok {
+			continue
+		}
+		v, err := version.Parse(name)
+		if err != nil {
+			continue
+		}
+		if date, ok := version.SnapshotDate(v); ok && date.Before(before) {
+			tags = append(tags, ref)
+		}
+	}
+	return tags
+}
+
+// TagExists reports whether the tag name exists
+func (r *Repo) TagExists(name string) bool {
+	for _, ref := range r.allReferences {
+		if ref.Name() == plumbing.NewTagReferenceName(name) {
+			return true
+		}
+	}
+	return false
+}
 
-	major, err := strconv.Atoi(versionNumber.FindString(latestVersionSlice[len(latestVersionSlice)-3]))
+// CreateSnapshotTag pushes the tag name pointing to the source branch, no release branch is created
+func (r *Repo) CreateSnapshotTag(ctx context.Context, name string) error {
+	tagger, err := optionalRemote[remote.TagCreator](r, "creating tags")
 	if err != nil {
-		return "", err
+		return err
+	}
+	return tagger.CreateTag(ctx, r.sourceBranch, name, r.tagMessage)
+}
+
+// TagMessages returns the messages of the annotated tags, lightweight tags have an empty message
+func (r *Repo) TagMessages(ctx context.Context, tags ...*plumbing.Reference) (map[plumbing.ReferenceName]string, error) {
+	reader, err := optionalRemote[remote.TagMessageReader](r, "reading tag messages")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]plumbing.ReferenceName, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name()
+	}
+	return reader.TagMessages(ctx, names...)
+}
+
+// PromoteCandidate creates the final release v of a release candidate: the tag name pointing to the commit of candidate,
+// annotated with message if set, and the release branch of v if branch is set
+func (r *Repo) PromoteCandidate(ctx context.Context, candidate *plumbing.Reference, v, name string, branch bool, message string) error {
+	tagger, err := optionalRemote[remote.TagCreator](r, "creating tags")
+	if err != nil {
+		return err
+	}
+	if branch {
+		if err := r.remoteBranch.CreateBranchAndTag(ctx, candidate, r.branchFilter, v, true, false); err != nil {
+			return err
+		}
+	}
+	return tagger.CreateTag(ctx, candidate, name, message)
+}
+
+// DeleteReferences deletes the branches and tags names on the remote
+func (r *Repo) DeleteReferences(ctx context.Context, names ...plumbing.ReferenceName) error {
+	deleter, err := optionalRemote[remote.ReferenceDeleter](r, "deleting references")
+	if err != nil {
+		return err
+	}
+	return deleter.DeleteReferences(ctx, names...)
+}
+
+// IsYanked reports whether the version v has been yanked and recorded below remote.YankedPrefix
+func (r *Repo) IsYanked(v string) bool {
+	scheme := r.versionScheme()
+	for _, ref := range r.allReferences {
+		name, ok := strings.CutPrefix(ref.Name().String(), remote.YankedPrefix)
+		if !ok {
+			continue
+		}
+		if yanked, err := scheme.Parse(name); err == nil && scheme.Compare(yanked, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Yank deletes the release branches and tags refs of the version v with a single push. If record is set, the yank is recorded
+// before as reference below remote.YankedPrefix pointing to the commit of the first ref, so v is never released again.
+func (r *Repo) Yank(ctx context.Context, v string, record bool, refs ...*plumbing.Reference) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	deleter, err := optionalRemote[remote.ReferenceDeleter](r, "deleting references")
+	if err != nil {
+		return err
 	}
-	minor, err := strconv.Atoi(latestVersionSlice[len(latestVersionSlice)-2])
+	if record && !r.IsYanked(v) {
+		creator, err := optionalRemote[remote.ReferenceCreator](r, "recording yanked versions")
+		if err != nil {
+			return err
+		}
+		if err := creator.CreateReference(ctx, plumbing.NewHashReference(remote.YankedReferenceName(v), refs[0].Hash())); err != nil {
+			return err
+		}
+	}
+	names := make([]plumbing.ReferenceName, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Name()
+	}
+	return deleter.DeleteReferences(ctx, names...)
+}
+
+// CommitTime returns the commit date of the commit ref points to
+func (r *Repo) CommitTime(ctx context.Context, ref *plumbing.Reference) (time.Time, error) {
+	times, err := r.CommitTimes(ctx, ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return times[ref.Name()], nil
+}
+
+// CommitTimes returns the commit dates of the commits refs point to by reference name, they are fetched at once
+func (r *Repo) CommitTimes(ctx context.Context, refs ...*plumbing.Reference) (map[plumbing.ReferenceName]time.Time, error) {
+	timer, err := optionalRemote[remote.CommitTimer](r, "reading commit dates")
+	if err != nil {
+		return nil, err
+	}
+	return timer.CommitTimes(ctx, refs...)
+}
+
+// ReadFile returns the content of the file path in the commit ref points to, a missing file returns object.ErrFileNotFound
+func (r *Repo) ReadFile(ctx context.Context, ref *plumbing.Reference, path string) (string, error) {
+	reader, err := optionalRemote[remote.FileReader](r, "reading files")
 	if err != nil {
 		return "", err
 	}
-	patch, err := strconv.Atoi(latestVersionSlice[len(latestVersionSlice)-1])
+	return reader.ReadFile(ctx, ref, path)
+}
+
+// Clone clones all branches and tags of the repo into memory, e.g. to merge release branches
+func (r *Repo) Clone(ctx context.Context) (*remote.Workspace, error) {
+	cloner, err := optionalRemote[remote.Cloner](r, "cloning")
+	if err != nil {
+		return nil, err
+	}
+	return cloner.Clone(ctx, r.remoteUrl)
+}
+
+// optionalRemote returns the remote of r as the optional interface T of operation,
+// if the remote doesn't implement T an error wrapping remote.ErrNotSupported is returned
+func optionalRemote[T any](r *Repo, operation string) (T, error) {
+	rem, ok := r.remoteBranch.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%s is %w", operation, remote.ErrNotSupported)
+	}
+	return rem, nil
+}
+
+// ReleaseVersion sets the version of the next release explicitly instead of calculating it like NextReleaseVersion.
+// The version must be valid in the scheme of the repo, e.g. v3.0.0. It must be greater than the version of GetLatestVersionReference,
+// which has to be called first, and must not exist as tag or release branch yet or have been yanked.
+func (r *Repo) ReleaseVersion(v string) (string, error) {
+	scheme := r.versionScheme()
+	next, err := scheme.Parse(v)
 	if err != nil {
 		return "", err
 	}
-	switch nextVersion {
-	case MAJOR:
-		r.nextReleaseVersion = semver.Canonical(fmt.Sprintf("v%v.%v.%v", major+1, 0, 0))
-	case MINOR:
-		r.nextReleaseVersion = semver.Canonical(fmt.Sprintf("v%v.%v.%v", major, minor+1, 0))
-	case PATCH:
-		r.nextReleaseVersion = semver.Canonical(fmt.Sprintf("v%v.%v.%v", major, minor, patch+1))
-	default:
-		r.nextReleaseVersion = fallBackVersion(nextVersion)
+	if r.line != nil {
+		if parsed, _ := version.Parse(next); !r.line.Contains(parsed) {
+			return "", fmt.Errorf("%w: %s doesn't belong to line %s", ErrOutsideLine, next, r.line)
+		}
+	}
+	if r.latestVersionReference != nil {
+		if latest := r.refVersion(r.latestVersionReference); latest != "" && scheme.Compare(next, latest) <= 0 {
+			return "", fmt.Errorf("%w: %s <= %s", ErrVersionNotGreater, next, r.latestVersionReference.Name().Short())
+		}
+	}
+	if ref := r.versionReference(next); ref != nil {
+		return "", fmt.Errorf("%w: %s", ErrVersionExists, ref.Name().Short())
 	}
-	return r.nextReleaseVersion, nil
+	if r.IsYanked(next) {
+		return "", fmt.Errorf("%w: %s", ErrVersionYanked, next)
+	}
+	r.nextReleaseVersion, r.skippedVersions = next, nil
+	return next, nil
 }
 
-func fallBackVersion(nextVersion int) string {
-	switch nextVersion {
-	case MAJOR:
-		return semver.Canonical(fmt.Sprintf("v%v.%v.%v", 1, 0, 0))
-	case MINOR:
-		return semver.Canonical(fmt.Sprintf("v%v.%v.%v", 0, 1, 0))
-	case PATCH:
-		return semver.Canonical(fmt.Sprintf("v%v.%v.%v", 0, 0, 1))
-	default:
-		return semver.Canonical(fmt.Sprintf("v%v.%v.%v", 0, 1, 0))
+// versionReference returns a tag or release branch with the same precedence as v
+func (r *Repo) versionReference(v string) *plumbing.Reference {
+	for _, ref := range r.allReferences {
+		name := ref.Name().Short()
+		if !ref.Name().IsTag() && !(ref.Name().IsBranch() && strings.Contains(name, r.branchFilter)) {
+			continue
+		}
+		if existing := r.refVersion(ref); existing != "" && r.versionScheme().Compare(existing, v) == 0 {
+			return ref
+		}
 	}
+	return nil
+}
+
+// IsReleased reports whether the source branch points to the same commit as the latest version reference,
+// CreateNewRelease won't create a new release in this case unless forced
+func (r *Repo) IsReleased() bool {
+	return r.latestVersionReference != nil && r.sourceBranch != nil && r.latestVersionReference.Hash() == r.sourceBranch.Hash()
 }
 
-func (r *Repo) CreateNewRelease(branch, tag, force bool) error {
+func (r *Repo) CreateNewRelease(ctx context.Context, branch, tag, force bool) error {
 	if r.latestVersionReference == nil {
-		log.Info().Msg("No current version branches / tags found")
-		return r.remoteBranch.CreateBranchAndTag(r.sourceBranch, r.branchFilter, r.nextReleaseVersion, branch, tag)
+		r.log().Info().Msg("No current version branches / tags found")
+		return r.remoteBranch.CreateBranchAndTag(ctx, r.sourceBranch, r.branchFilter, r.nextReleaseVersion, branch, tag)
 	}
 
 	if r.latestVersionReference.Name().IsTag() {
 		if r.latestVersionReference.Hash().String() == r.sourceBranch.Hash().String() && !force {
-			log.Info().Msgf("Nothing to do, %s branch and latest tag version %s are equals, commit hash: %s", r.sourceBranch.Name().Short(), r.latestVersionReference.Name().Short(), r.sourceBranch.Hash())
+			r.log().Info().Msgf("Nothing to do, %s branch and latest tag version %s are equals, commit hash: %s", r.sourceBranch.Name().Short(), r.latestVersionReference.Name().Short(), r.sourceBranch.Hash())
 			return nil
 		}
 	}
 
 	if r.latestVersionReference.Hash().String() == r.sourceBranch.Hash().String() && !force {
-		log.Info().Msgf("Nothing to do, %s and latest branch version %s are equals, commit hash: %s", r.sourceBranch.Name().Short(), r.latestVersionReference.Name().Short(), r.sourceBranch.Hash())
+		r.log().Info().Msgf("Nothing to do, %s and latest branch version %s are equals, commit hash: %s", r.sourceBranch.Name().Short(), r.latestVersionReference.Name().Short(), r.sourceBranch.Hash())
 		return nil
 	}
 
-	return r.remoteBranch.CreateBranchAndTag(r.sourceBranch, r.branchFilter, r.nextReleaseVersion, branch, tag)
+	return r.remoteBranch.CreateBranchAndTag(ctx, r.sourceBranch, r.branchFilter, r.nextReleaseVersion, branch, tag)
 }
@@ -1,9 +1,11 @@
 package repo
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/object"
 
@@ -14,7 +16,9 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/fhopfensperger/git-releaser/pkg/remote"
+	"github.com/fhopfensperger/git-releaser/pkg/version"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -360,6 +364,20 @@ func TestRepo_NextReleaseVersion(t *testing.T) {
 			want:    "v0.0.1",
 			wantErr: false,
 		},
+		{
+			name:    "multi-digit major",
+			fields:  fields{plumbing.NewHashReference(plumbing.NewTagReferenceName("v12.3.4"), plumbing.Hash{})},
+			args:    args{MAJOR},
+			want:    "v13.0.0",
+			wantErr: false,
+		},
+		{
+			name:    "pre-release",
+			fields:  fields{plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.3.0-rc.2"), plumbing.Hash{})},
+			args:    args{MINOR},
+			want:    "v1.3.0",
+			wantErr: false,
+		},
 		{
 			name:    "invalid version branch reference",
 			fields:  fields{plumbing.NewHashReference("vmain.main.main", plumbing.Hash{})},
@@ -385,20 +403,74 @@ func TestRepo_NextReleaseVersion(t *testing.T) {
 	}
 }
 
+func TestRepo_version_references(t *testing.T) {
+	ref := func(name plumbing.ReferenceName) *plumbing.Reference {
+		return plumbing.NewHashReference(name, plumbing.ZeroHash)
+	}
+	migration := ref(plumbing.NewBranchReferenceName("release/v2-migration"))
+	embedded := ref(plumbing.NewBranchReferenceName("release/v2.0-migration"))
+	short := ref(plumbing.NewTagReferenceName("v3.1"))
+	numbered := ref(plumbing.NewBranchReferenceName("release-1.2.3"))
+	prefixed := ref(plumbing.NewTagReferenceName("app-v12.0.0"))
+	r := &Repo{allReferences: []*plumbing.Reference{migration, embedded, short, numbered, prefixed}}
+
+	assert.Equal(t, []*plumbing.Reference{numbered}, r.GetVersionBranches("release"))
+	assert.Equal(t, []*plumbing.Reference{prefixed}, r.GetVersionTags())
+	assert.Equal(t, prefixed, r.GetLatestVersionReference())
+}
+
+func TestRepo_ReleaseVersion(t *testing.T) {
+	refs := append(generateBranchPlumbReferences(), generateTagsPlumbReferences()...)
+	refs = append(refs, plumbing.NewHashReference("refs/yanked/v3.0.1", plumbing.NewHash("a1")))
+	tests := []struct {
+		name    string
+		version string
+		latest  *plumbing.Reference
+		want    string
+		wantErr error
+	}{
+		{name: "greater major", version: "v3.0.0", latest: d, want: "v3.0.0"},
+		{name: "without v", version: "3.0.0", latest: d, want: "v3.0.0"},
+		{name: "pre-release and build metadata", version: "v3.0.0-rc.1+build.5", latest: d, want: "v3.0.0-rc.1+build.5"},
+		{name: "no previous release", version: "v0.1.0", want: "v0.1.0"},
+		{name: "short version", version: "v3", latest: d, wantErr: ErrInvalidVersion},
+		{name: "invalid", version: "three", latest: d, wantErr: ErrInvalidVersion},
+		{name: "equal", version: "v2.10.79", latest: d, wantErr: ErrVersionNotGreater},
+		{name: "lower", version: "v2.9.0", latest: d, wantErr: ErrVersionNotGreater},
+		{name: "existing older tag", version: "v2.1.79", wantErr: ErrVersionExists},
+		{name: "existing branch", version: "1.0.9", wantErr: ErrVersionExists},
+		{name: "part of an existing version", version: "v0.10.9", want: "v0.10.9"},
+		{name: "yanked", version: "v3.0.1", latest: d, wantErr: ErrVersionYanked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repo{allReferences: refs, branchFilter: "release", latestVersionReference: tt.latest}
+			got, err := r.ReleaseVersion(tt.version)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want, r.nextReleaseVersion)
+		})
+	}
+}
+
 type repoMock struct {
 	mock.Mock
 }
 
-func (m *repoMock) CreateBranchAndTag(sourceBranch *plumbing.Reference, targetBranch, version string, createBranch, createTag bool) error {
+func (m *repoMock) CreateBranchAndTag(ctx context.Context, sourceBranch *plumbing.Reference, targetBranch, version string, createBranch, createTag bool) error {
 	fmt.Println("Mocked CreateBranchAndTag() function")
 	args := m.Called(sourceBranch, targetBranch, version, createBranch, createTag)
 	return args.Error(0)
 }
 
-func (m *repoMock) GetAllRemoteBranchesAndTags(repoURL string) []*plumbing.Reference {
+func (m *repoMock) GetAllRemoteBranchesAndTags(ctx context.Context, repoURL string) ([]*plumbing.Reference, error) {
 	fmt.Println("Mocked GetAllRemoteBranchesAndTags() function")
 	args := m.Called(repoURL)
-	return args.Get(0).([]*plumbing.Reference)
+	return args.Get(0).([]*plumbing.Reference), args.Error(1)
 }
 
 func (m *repoMock) GetStorer() storage.Storer {
@@ -407,6 +479,36 @@ func (m *repoMock) GetStorer() storage.Storer {
 	return args.Get(0).(storage.Storer)
 }
 
+func (m *repoMock) CreateTag(ctx context.Context, ref *plumbing.Reference, name, message string) error {
+	args := m.Called(ref, name, message)
+	return args.Error(0)
+}
+
+func (m *repoMock) DeleteReferences(ctx context.Context, names ...plumbing.ReferenceName) error {
+	args := m.Called(names)
+	return args.Error(0)
+}
+
+func (m *repoMock) CommitTimes(ctx context.Context, refs ...*plumbing.Reference) (map[plumbing.ReferenceName]time.Time, error) {
+	args := m.Called(refs)
+	return args.Get(0).(map[plumbing.ReferenceName]time.Time), args.Error(1)
+}
+
+func (m *repoMock) TagMessages(ctx context.Context, names ...plumbing.ReferenceName) (map[plumbing.ReferenceName]string, error) {
+	args := m.Called(names)
+	return args.Get(0).(map[plumbing.ReferenceName]string), args.Error(1)
+}
+
+func (m *repoMock) CreateReference(ctx context.Context, ref *plumbing.Reference) error {
+	args := m.Called(ref)
+	return args.Error(0)
+}
+
+func (m *repoMock) ReadFile(ctx context.Context, ref *plumbing.Reference, path string) (string, error) {
+	args := m.Called(ref, path)
+	return args.String(0), args.Error(1)
+}
+
 func TestRepo_CreateNewRelease(t *testing.T) {
 	tag_v1_0_0 := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), plumbing.NewHash("40448c70cf1ac313d22aa2b2454ca68baa122542"))
 	tag_v2_0_0 := plumbing.NewHashReference(plumbing.NewTagReferenceName("v2.0.0"), plumbing.NewHash("12448"))
@@ -684,14 +786,14 @@ func TestRepo_CreateNewRelease(t *testing.T) {
 				remoteBranch:           tt.fields.remoteBranch,
 				branchFilter:           tt.fields.branchFilter,
 			}
-			if err := r.CreateNewRelease(tt.args.branch, tt.args.tag, tt.args.force); (err != nil) != tt.wantErr {
+			if err := r.CreateNewRelease(context.Background(), tt.args.branch, tt.args.tag, tt.args.force); (err != nil) != tt.wantErr {
 				t.Errorf("CreateNewRelease() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func Test_fallBackVersion(t *testing.T) {
+func TestRepo_NextReleaseVersion_initial(t *testing.T) {
 	type args struct {
 		nextVersion int
 	}
@@ -723,9 +825,110 @@ func Test_fallBackVersion(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := fallBackVersion(tt.args.nextVersion); got != tt.want {
-				t.Errorf("fallBackVersion() = %v, want %v", got, tt.want)
+			if got, _ := (&Repo{}).NextReleaseVersion(tt.args.nextVersion); got != tt.want {
+				t.Errorf("NextReleaseVersion() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestRepo_NextReleaseVersion_yanked(t *testing.T) {
+	latest := plumbing.NewHashReference("refs/tags/v1.0.4", plumbing.NewHash("a1"))
+	r := &Repo{
+		allReferences: []*plumbing.Reference{
+			latest,
+			plumbing.NewHashReference("refs/yanked/v1.0.5", plumbing.NewHash("a2")),
+			plumbing.NewHashReference("refs/yanked/v1.0.6", plumbing.NewHash("a3")),
+			plumbing.NewHashReference("refs/yanked/latest", plumbing.NewHash("a4")),
+		},
+		latestVersionReference: latest,
+	}
+
+	got, err := r.NextReleaseVersion(PATCH)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.7", got)
+	assert.Equal(t, []string{"v1.0.5", "v1.0.6"}, r.SkippedVersions())
+	got, err = r.NextReleaseVersion(MINOR)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.1.0", got)
+	assert.Empty(t, r.SkippedVersions())
+	assert.True(t, r.IsYanked("1.0.5"))
+	assert.False(t, r.IsYanked("v1.0.4"))
+}
+
+func TestRepo_IsReleased(t *testing.T) {
+	released := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), main.Hash())
+
+	assert.False(t, (&Repo{sourceBranch: main}).IsReleased())
+	assert.False(t, (&Repo{sourceBranch: main, latestVersionReference: e}).IsReleased())
+	assert.True(t, (&Repo{sourceBranch: main, latestVersionReference: released}).IsReleased())
+}
+
+func TestNew(t *testing.T) {
+	remoteBranchMock := new(repoMock)
+	remoteBranchMock.On("GetAllRemoteBranchesAndTags", "https://github.com/acme/api.git").Return([]*plumbing.Reference{main}, nil)
+
+	r, err := New(context.Background(), "https://github.com/acme/api.git", WithRemote(remoteBranchMock))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*plumbing.Reference{main}, r.allReferences)
+	assert.Equal(t, main, r.GetSourceBranch("main"))
+	remoteBranchMock.AssertExpectations(t)
+}
+
+func TestNew_error(t *testing.T) {
+	notFound := &remote.NotFoundError{URL: "https://github.com/acme/api.git", Err: transport.ErrRepositoryNotFound}
+	remoteBranchMock := new(repoMock)
+	remoteBranchMock.On("GetAllRemoteBranchesAndTags", "https://github.com/acme/api.git").Return([]*plumbing.Reference(nil), notFound)
+
+	r, err := New(context.Background(), "https://github.com/acme/api.git", WithRemote(remoteBranchMock))
+
+	assert.Nil(t, r)
+	var notFoundErr *remote.NotFoundError
+	assert.ErrorAs(t, err, &notFoundErr)
+	assert.ErrorIs(t, err, transport.ErrRepositoryNotFound)
+}
+
+// baseRemote only implements remote.CreateBranchAndTager without any optional interface
+type baseRemote struct {
+	remote.CreateBranchAndTager
+}
+
+func TestRepo_optional_remote_not_supported(t *testing.T) {
+	ctx := context.Background()
+	tag := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.0.0"), main.Hash())
+	r := &Repo{remoteBranch: baseRemote{new(repoMock)}, sourceBranch: main, allReferences: []*plumbing.Reference{main, tag}}
+
+	assert.ErrorIs(t, r.CreateSnapshotTag(ctx, "nightly/v1.0.1"), remote.ErrNotSupported)
+	assert.ErrorIs(t, r.PromoteCandidate(ctx, tag, "v1.0.0", "v1.0.0", false, ""), remote.ErrNotSupported)
+	assert.ErrorIs(t, r.DeleteReferences(ctx, tag.Name()), remote.ErrNotSupported)
+	assert.ErrorIs(t, r.Yank(ctx, "v1.0.0", true, tag), remote.ErrNotSupported)
+	_, err := r.TagMessages(ctx, tag)
+	assert.ErrorIs(t, err, remote.ErrNotSupported)
+	_, err = r.CommitTime(ctx, main)
+	assert.ErrorContains(t, err, "reading commit dates is not supported by remote")
+	_, err = r.ReadFile(ctx, main, "go.mod")
+	assert.ErrorIs(t, err, remote.ErrNotSupported)
+	_, err = r.Clone(ctx)
+	assert.ErrorIs(t, err, remote.ErrNotSupported)
+}
+
+func TestRepo_SetHotfixLine(t *testing.T) {
+	source := plumbing.NewHashReference(plumbing.NewBranchReferenceName("release/v1.3.2"), plumbing.NewHash("b2"))
+	r := &Repo{sourceBranch: source, allReferences: []*plumbing.Reference{source, e, f}}
+
+	assert.ErrorIs(t, r.SetHotfixLine(version.Line{Major: 1, Minor: 4}), ErrOutsideLine)
+	assert.NoError(t, r.SetHotfixLine(version.Line{Major: 1, Minor: 3}))
+	assert.Empty(t, r.GetVersionBranches("release"), "the source branch isn't a release")
+	assert.Empty(t, r.GetVersionTags())
+	assert.Nil(t, r.GetLatestVersionReference())
+
+	next, err := r.NextReleaseVersion(PATCH)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.3.3", next)
+
+	calver, err := version.NewCalVer("", nil)
+	assert.NoError(t, err)
+	r = &Repo{scheme: calver}
+	assert.ErrorIs(t, r.SetHotfixLine(version.Line{Major: 1, Minor: 3}), ErrHotfixScheme)
+}
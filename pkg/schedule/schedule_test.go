@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	// a wednesday
+	from := time.Date(2026, 10, 14, 9, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"* * * * *", nil, time.Date(2026, 10, 14, 9, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", nil, time.Date(2026, 10, 14, 9, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", nil, time.Date(2026, 10, 14, 9, 45, 0, 0, time.UTC)},
+		{"0 10 * * *", nil, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * * TUE", nil, time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)},
+		{"0 10 * * mon-fri", nil, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1,5", nil, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", nil, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", nil, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", nil, time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", nil, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week if both are restricted
+		{"0 0 20 * FRI", nil, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"@daily", nil, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", nil, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 10 * * *", berlin, time.Date(2026, 10, 15, 10, 0, 0, 0, berlin)},
+		// half-hour offset, the full hours in UTC are half hours in the location
+		{"0 10 * * *", kolkata, time.Date(2026, 10, 15, 10, 0, 0, 0, kolkata)},
+		{"0 16 * * *", kolkata, time.Date(2026, 10, 14, 16, 0, 0, 0, kolkata)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := Parse(tt.expr, tt.loc)
+			require.NoError(t, err)
+			got := c.Next(from)
+			assert.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+		})
+	}
+}
+
+func TestCron_Next_is_strictly_after(t *testing.T) {
+	c, err := Parse("0 10 * * *", nil)
+	require.NoError(t, err)
+	slot := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, slot.AddDate(0, 0, 1), c.Next(slot))
+	assert.Equal(t, slot, c.Next(slot.Add(-time.Second)))
+}
+
+func TestCron_Next_never(t *testing.T) {
+	c, err := Parse("0 0 30 2 *", nil)
+	require.NoError(t, err)
+
+	assert.True(t, c.Next(time.Now()).IsZero())
+}
+
+func TestParse_errors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * foo *", "@reboot"} {
+		_, err := Parse(expr, nil)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, s.LastRuns)
+
+	run := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	s.LastRuns["train"] = run
+	require.NoError(t, s.Save(path))
+
+	s, err = LoadState(path)
+	require.NoError(t, err)
+	assert.True(t, run.Equal(s.LastRuns["train"]))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(t, entries, 1, "no temporary files are left")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = LoadState(path)
+	assert.Error(t, err)
+}
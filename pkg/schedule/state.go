@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State records the last run of every schedule by name, it is persisted to survive restarts
+type State struct {
+	LastRuns map[string]time.Time `json:"last_runs"`
+}
+
+// LoadState reads the state from path, a missing file results in an empty state
+func LoadState(path string) (*State, error) {
+	s := &State{LastRuns: map[string]time.Time{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("invalid schedule state %s: %w", path, err)
+	}
+	if s.LastRuns == nil {
+		s.LastRuns = map[string]time.Time{}
+	}
+	return s, nil
+}
+
+// Save writes the state to path, the file is replaced atomically so a crash never leaves a partial state
+func (s *State) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,221 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCalVerFormat creates versions like 2026.10.3
+const DefaultCalVerFormat = "YYYY.0M.MICRO"
+
+// calVerTokens are the tokens of a CalVer format, see https://calver.org.
+// The rank defines their order within a format: year, month or week, day and the counters MAJOR, MINOR, MICRO.
+var calVerTokens = map[string]struct {
+	rank int
+	// padded tokens have two digits at least, e.g. 05
+	padded bool
+	// min and max limit date tokens, max 0 means unlimited
+	min, max uint64
+	value    func(time.Time) uint64
+}{
+	"YYYY":  {rank: 0, min: 1000, max: 9999, value: func(t time.Time) uint64 { return uint64(t.Year()) }},
+	"YY":    {rank: 0, value: func(t time.Time) uint64 { return uint64(t.Year() - 2000) }},
+	"0Y":    {rank: 0, padded: true, value: func(t time.Time) uint64 { return uint64(t.Year() - 2000) }},
+	"MM":    {rank: 1, min: 1, max: 12, value: func(t time.Time) uint64 { return uint64(t.Month()) }},
+	"0M":    {rank: 1, padded: true, min: 1, max: 12, value: func(t time.Time) uint64 { return uint64(t.Month()) }},
+	"WW":    {rank: 1, min: 1, max: 53, value: week},
+	"0W":    {rank: 1, padded: true, min: 1, max: 53, value: week},
+	"DD":    {rank: 2, min: 1, max: 31, value: func(t time.Time) uint64 { return uint64(t.Day()) }},
+	"0D":    {rank: 2, padded: true, min: 1, max: 31, value: func(t time.Time) uint64 { return uint64(t.Day()) }},
+	"MAJOR": {rank: 3},
+	"MINOR": {rank: 4},
+	"MICRO": {rank: 5},
+}
+
+// counterRank is the rank of the first counter token
+const counterRank = 3
+
+// week is the week since the start of the year, the first week is 1
+func week(t time.Time) uint64 {
+	return uint64((t.YearDay()-1)/7 + 1)
+}
+
+// CalVer is a calendar versioning scheme like 2026.10.3, see https://calver.org
+type CalVer struct {
+	// Format are dot separated tokens, YYYY, YY or 0Y for the year, MM, 0M, WW or 0W for the month or week,
+	// DD or 0D for the day, followed by the counters MAJOR, MINOR and / or MICRO, e.g. YYYY.0M.MICRO
+	Format string
+	// Now returns the date of new versions, nil uses time.Now
+	Now func() time.Time
+}
+
+// NewCalVer validates format and returns its CalVer scheme, an empty format uses DefaultCalVerFormat
+func NewCalVer(format string, now func() time.Time) (CalVer, error) {
+	if format == "" {
+		format = DefaultCalVerFormat
+	}
+	c := CalVer{Format: format, Now: now}
+	rank := -1
+	for _, t := range c.tokens() {
+		token, ok := calVerTokens[t]
+		if !ok {
+			return c, fmt.Errorf("invalid CalVer format %q: unknown token %q", format, t)
+		}
+		if token.rank <= rank {
+			return c, fmt.Errorf("invalid CalVer format %q: %s is out of order, expected year, month or week, day, MAJOR, MINOR, MICRO", format, t)
+		}
+		rank = token.rank
+	}
+	if calVerTokens[c.tokens()[0]].rank != 0 {
+		return c, fmt.Errorf("invalid CalVer format %q: it has to start with the year", format)
+	}
+	return c, nil
+}
+
+func (c CalVer) tokens() []string {
+	format := c.Format
+	if format == "" {
+		format = DefaultCalVerFormat
+	}
+	return strings.Split(format, ".")
+}
+
+func (c CalVer) now() time.Time {
+	if c.Now == nil {
+		return time.Now()
+	}
+	return c.Now()
+}
+
+// Parse returns v without a leading v, it has to match the format
+func (c CalVer) Parse(v string) (string, error) {
+	values, err := c.parse(v)
+	if err != nil {
+		return "", err
+	}
+	return c.format(values), nil
+}
+
+// FromRef returns the version at the end of name like release/2026.10.3 or app-2026.10.3
+func (c CalVer) FromRef(name string) (string, bool) {
+	s, ok := versionFromRef(name, func(s string) bool {
+		_, err := c.parse(s)
+		return err == nil
+	})
+	if !ok {
+		return "", false
+	}
+	v, _ := c.Parse(s)
+	return v, true
+}
+
+// Compare compares the tokens of a and b in the order of the format
+func (c CalVer) Compare(a, b string) int {
+	va, errA := c.parse(a)
+	vb, errB := c.parse(b)
+	if errA != nil || errB != nil {
+		return compareValidity(errA == nil, errB == nil)
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return compareNumbers(va[i], vb[i])
+		}
+	}
+	return 0
+}
+
+// Next returns the version of today with all counters reset to 0, if the date part of latest is older.
+// Otherwise the counter of part is incremented, MICRO for Patch, which falls back to the last counter of the format.
+// Incrementing a counter resets the following ones.
+func (c CalVer) Next(latest string, part Part) (string, error) {
+	tokens := c.tokens()
+	next := make([]uint64, len(tokens))
+	now := c.now()
+	for i, t := range tokens {
+		if token := calVerTokens[t]; token.rank < counterRank {
+			next[i] = token.value(now)
+		}
+	}
+	if latest == "" {
+		return c.format(next), nil
+	}
+	values, err := c.parse(latest)
+	if err != nil {
+		return "", err
+	}
+	for i, t := range tokens {
+		if calVerTokens[t].rank >= counterRank {
+			break
+		}
+		if next[i] != values[i] {
+			if next[i] > values[i] {
+				return c.format(next), nil
+			}
+			// the clock is behind the latest version, which must never result in a lower version
+			break
+		}
+	}
+
+	counter := -1
+	for i, t := range tokens {
+		if rank := calVerTokens[t].rank; rank >= counterRank {
+			counter = i
+			if rank-counterRank == int(part) {
+				break
+			}
+		}
+	}
+	if counter < 0 {
+		return "", fmt.Errorf("%w: %s already exists and format %s has no MAJOR, MINOR or MICRO counter", errNoCounter, latest, c.Format)
+	}
+	values[counter]++
+	for i := counter + 1; i < len(values); i++ {
+		values[i] = 0
+	}
+	return c.format(values), nil
+}
+
+// errNoCounter is returned by CalVer.Next if a second version of the same date is requested, but the format has no counter
+var errNoCounter = errors.New("no counter")
+
+// parse returns the numbers of the tokens of v, an optional leading v is ignored
+func (c CalVer) parse(v string) ([]uint64, error) {
+	tokens := c.tokens()
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != len(tokens) {
+		return nil, fmt.Errorf("%w %q: expected format %s", ErrInvalid, v, c.Format)
+	}
+	values := make([]uint64, len(parts))
+	for i, p := range parts {
+		token := calVerTokens[tokens[i]]
+		valid := isNumber(p)
+		if token.padded {
+			// padded numbers have two digits at least, longer ones must not have a leading zero
+			valid = len(p) == 2 && strings.Trim(p, "0123456789") == "" || len(p) > 2 && isNumber(p)
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w %q: %q doesn't match %s", ErrInvalid, v, p, tokens[i])
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil || n < token.min || (token.max > 0 && n > token.max) {
+			return nil, fmt.Errorf("%w %q: %q is out of range for %s", ErrInvalid, v, p, tokens[i])
+		}
+		values[i] = n
+	}
+	return values, nil
+}
+
+func (c CalVer) format(values []uint64) string {
+	parts := make([]string, len(values))
+	for i, t := range c.tokens() {
+		if calVerTokens[t].padded {
+			parts[i] = fmt.Sprintf("%02d", values[i])
+		} else {
+			parts[i] = strconv.FormatUint(values[i], 10)
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,128 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clock(date string) func() time.Time {
+	return func() time.Time {
+		t, _ := time.Parse("2006-01-02", date)
+		return t
+	}
+}
+
+func TestNewCalVer(t *testing.T) {
+	for _, format := range []string{"", "YYYY.0M.MICRO", "YY.MINOR.MICRO", "0Y.0W.MICRO", "YYYY.MM.DD", "YYYY.MAJOR.MINOR.MICRO"} {
+		_, err := NewCalVer(format, nil)
+		assert.NoError(t, err, format)
+	}
+	for _, format := range []string{"YYYY.0M.PATCH", "MICRO.YYYY", "0M.YYYY.MICRO", "YYYY.YY.MICRO", "YYYY.MICRO.MINOR", "YYYY..MICRO", "MAJOR.MINOR"} {
+		_, err := NewCalVer(format, nil)
+		assert.Error(t, err, format)
+	}
+}
+
+func TestCalVer_Parse(t *testing.T) {
+	c, err := NewCalVer("YYYY.0M.MICRO", nil)
+	require.NoError(t, err)
+
+	for in, want := range map[string]string{"2026.10.3": "2026.10.3", "v2026.01.0": "2026.01.0", "2026.12.15": "2026.12.15"} {
+		got, err := c.Parse(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+	for _, in := range []string{"2026.1.3", "2026.13.3", "2026.00.3", "26.10.3", "2026.10.03", "2026.10", "2026.10.3.1", "v1.2.3", "2026.10.3-rc.1"} {
+		_, err := c.Parse(in)
+		assert.ErrorIs(t, err, ErrInvalid, in)
+	}
+
+	short, err := NewCalVer("YY.MINOR.MICRO", nil)
+	require.NoError(t, err)
+	got, err := short.Parse("26.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "26.2.0", got)
+	_, err = short.Parse("06.2.0")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestCalVer_FromRef(t *testing.T) {
+	c, err := NewCalVer("YYYY.0M.MICRO", nil)
+	require.NoError(t, err)
+
+	for name, want := range map[string]string{"2026.10.3": "2026.10.3", "release/2026.10.3": "2026.10.3", "app-v2026.10.3": "2026.10.3"} {
+		got, ok := c.FromRef(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got)
+	}
+	for _, name := range []string{"v1.2.3", "feature/2026.10-cleanup", "main"} {
+		_, ok := c.FromRef(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestCalVer_Compare(t *testing.T) {
+	c, err := NewCalVer("YYYY.0M.MICRO", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, -1, c.Compare("2026.10.3", "2026.10.10"))
+	assert.Equal(t, -1, c.Compare("2026.09.12", "2026.10.0"))
+	assert.Equal(t, 1, c.Compare("2027.01.0", "2026.12.9"))
+	assert.Equal(t, 0, c.Compare("2026.10.3", "v2026.10.3"))
+	assert.Equal(t, -1, c.Compare("v1.2.3", "2026.10.3"))
+}
+
+func TestCalVer_Next(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		today  string
+		latest string
+		part   Part
+		want   string
+	}{
+		{"first version", "YYYY.0M.MICRO", "2026-10-17", "", Patch, "2026.10.0"},
+		{"same month", "YYYY.0M.MICRO", "2026-10-17", "2026.10.3", Patch, "2026.10.4"},
+		{"new month resets micro", "YYYY.0M.MICRO", "2026-11-02", "2026.10.3", Patch, "2026.11.0"},
+		{"new year", "YYYY.0M.MICRO", "2027-01-01", "2026.12.7", Minor, "2027.01.0"},
+		{"clock behind", "YYYY.0M.MICRO", "2026-09-30", "2026.10.3", Patch, "2026.10.4"},
+		{"missing counter falls back to micro", "YYYY.0M.MICRO", "2026-10-17", "2026.10.3", Major, "2026.10.4"},
+		{"minor resets micro", "YY.MINOR.MICRO", "2026-10-17", "26.2.5", Minor, "26.3.0"},
+		{"micro", "YY.MINOR.MICRO", "2026-10-17", "26.2.5", Patch, "26.2.6"},
+		{"new year resets all counters", "YY.MINOR.MICRO", "2027-01-04", "26.2.5", Patch, "27.0.0"},
+		{"week", "YYYY.0W.MICRO", "2026-01-08", "2026.01.0", Patch, "2026.02.0"},
+		{"day", "YYYY.MM.DD", "2026-10-17", "2026.10.16", Patch, "2026.10.17"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCalVer(tt.format, clock(tt.today))
+			require.NoError(t, err)
+			got, err := c.Next(tt.latest, tt.part)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCalVer_Next_without_counter(t *testing.T) {
+	c, err := NewCalVer("YYYY.MM.DD", clock("2026-10-17"))
+	require.NoError(t, err)
+
+	_, err = c.Next("2026.10.17", Patch)
+
+	assert.ErrorIs(t, err, errNoCounter)
+}
+
+func TestSemVer_Next(t *testing.T) {
+	for part, want := range map[Part]string{Major: "v1.0.0", Minor: "v0.1.0", Patch: "v0.0.1"} {
+		got, err := SemVer{}.Next("", part)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	got, err := SemVer{}.Next("v12.3.4", Major)
+	assert.NoError(t, err)
+	assert.Equal(t, "v13.0.0", got)
+}
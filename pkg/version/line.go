@@ -0,0 +1,90 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Line is a release line like v1.3, which contains all versions with the same major and minor number,
+// or a major line like v1, which contains all versions with the same major number.
+// Hotfixes of a line only increment the numbers below the line, e.g. the patch number of v1.3.
+type Line struct {
+	Major, Minor uint64
+	// MajorOnly is set for major lines like v1, Minor is 0 for them
+	MajorOnly bool
+}
+
+// ParseLine parses a release line with an optional leading v like v1.3 or v1, a complete version like v1.3.2 returns its minor line
+func ParseLine(s string) (Line, error) {
+	if v, err := Parse(s); err == nil {
+		return LineOf(v, Minor), nil
+	}
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) > 2 {
+		return Line{}, fmt.Errorf("%w line %q: expected major.minor like v1.3 or major like v1", ErrInvalid, s)
+	}
+	numbers := make([]uint64, len(parts))
+	for i, p := range parts {
+		if !isNumber(p) {
+			return Line{}, fmt.Errorf("%w line %q: expected major.minor like v1.3 or major like v1", ErrInvalid, s)
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return Line{}, fmt.Errorf("%w line %q: number out of range", ErrInvalid, s)
+		}
+		numbers[i] = n
+	}
+	if len(numbers) == 1 {
+		return Line{Major: numbers[0], MajorOnly: true}, nil
+	}
+	return Line{Major: numbers[0], Minor: numbers[1]}, nil
+}
+
+// LineFromRef returns the line at the end of a branch name like release/v1.3 or release/v1, see FromRef
+func LineFromRef(name string) (Line, bool) {
+	s, ok := versionFromRef(name, func(s string) bool {
+		_, err := ParseLine(s)
+		return err == nil
+	})
+	if !ok {
+		return Line{}, false
+	}
+	l, _ := ParseLine(s)
+	return l, true
+}
+
+// LineOf returns the line of v up to part, e.g. v1.3 for v1.3.2 and Minor or v1 for Major. Patch returns the minor line.
+func LineOf(v Version, part Part) Line {
+	if part == Major {
+		return Line{Major: v.Major, MajorOnly: true}
+	}
+	return Line{Major: v.Major, Minor: v.Minor}
+}
+
+// Part returns the number a line is defined by, Major for major lines like v1 and Minor otherwise.
+// Releases within the line must only increment numbers after it.
+func (l Line) Part() Part {
+	if l.MajorOnly {
+		return Major
+	}
+	return Minor
+}
+
+// First returns the first version of the line, e.g. v1.3.0 for v1.3
+func (l Line) First() Version {
+	return Version{Major: l.Major, Minor: l.Minor}
+}
+
+// Contains reports whether v belongs to the line
+func (l Line) Contains(v Version) bool {
+	return LineOf(v, l.Part()) == l
+}
+
+// String returns the line with a leading v, e.g. v1.3 or v1
+func (l Line) String() string {
+	if l.MajorOnly {
+		return fmt.Sprintf("v%d", l.Major)
+	}
+	return fmt.Sprintf("v%d.%d", l.Major, l.Minor)
+}
@@ -0,0 +1,58 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := map[string]Line{
+		"v1.3":        {Major: 1, Minor: 3},
+		"1.3":         {Major: 1, Minor: 3},
+		"v0.0":        {},
+		"v1.3.2":      {Major: 1, Minor: 3},
+		"v2.0.0-rc.1": {Major: 2},
+		"v1":          {Major: 1, MajorOnly: true},
+		"12":          {Major: 12, MajorOnly: true},
+	}
+	for in, want := range tests {
+		got, err := ParseLine(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "v", "v1.", "v1.03", "v01", "v1.3.x", "release/v1.3", "v99999999999999999999.0"} {
+		_, err := ParseLine(in)
+		assert.ErrorIs(t, err, ErrInvalid, in)
+	}
+}
+
+func TestLineFromRef(t *testing.T) {
+	for name, want := range map[string]Line{"release/v1.4": {Major: 1, Minor: 4}, "release/v1": {Major: 1, MajorOnly: true}, "support-1.4": {Major: 1, Minor: 4}} {
+		got, ok := LineFromRef(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, got, name)
+	}
+	for _, name := range []string{"main", "feature/v2-migration", "release/v1.x"} {
+		_, ok := LineFromRef(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestLine_Contains(t *testing.T) {
+	line := Line{Major: 1, Minor: 3}
+
+	assert.True(t, line.Contains(Version{Major: 1, Minor: 3, Patch: 7}))
+	assert.True(t, line.Contains(Version{Major: 1, Minor: 3, PreRelease: "rc.1"}))
+	assert.False(t, line.Contains(Version{Major: 1, Minor: 4}))
+	assert.False(t, line.Contains(Version{Major: 2, Minor: 3}))
+	assert.Equal(t, "v1.3", line.String())
+	assert.Equal(t, Version{Major: 1, Minor: 3}, line.First())
+
+	major := Line{Major: 1, MajorOnly: true}
+	assert.True(t, major.Contains(Version{Major: 1, Minor: 4, Patch: 2}))
+	assert.False(t, major.Contains(Version{Major: 2}))
+	assert.Equal(t, "v1", major.String())
+	assert.Equal(t, Major, major.Part())
+	assert.Equal(t, major, LineOf(Version{Major: 1, Minor: 4}, Major))
+}
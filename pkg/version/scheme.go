@@ -0,0 +1,112 @@
+package version
+
+import "strings"
+
+// Part is the number of a version which is incremented for a new release
+type Part int
+
+const (
+	Major Part = iota
+	Minor
+	Patch
+)
+
+// Scheme is a versioning scheme like semantic or calendar versioning.
+// Versions are passed as strings in the form returned by Parse.
+type Scheme interface {
+	// Parse validates a version and returns it in its canonical form, invalid versions return ErrInvalid
+	Parse(v string) (string, error)
+	// FromRef returns the version at the end of a branch or tag name, e.g. v1.2.3 of release/v1.2.3
+	FromRef(name string) (string, bool)
+	// Compare returns -1, 0 or +1 depending on the precedence of a and b, invalid versions come first
+	Compare(a, b string) int
+	// Next returns the version following latest, an empty latest returns the first version of a repo
+	Next(latest string, part Part) (string, error)
+}
+
+// SemVer is the semantic versioning scheme, see Parse
+type SemVer struct{}
+
+// Parse returns v with a leading v
+func (SemVer) Parse(v string) (string, error) {
+	parsed, err := Parse(v)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// FromRef returns the version of name, see FromRef
+func (SemVer) FromRef(name string) (string, bool) {
+	v, ok := FromRef(name)
+	if !ok {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// Compare compares a and b, see Compare
+func (SemVer) Compare(a, b string) int {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	if errA != nil || errB != nil {
+		return compareValidity(errA == nil, errB == nil)
+	}
+	return Compare(va, vb)
+}
+
+// Next bumps part of latest. The first version of a repo is v1.0.0, v0.1.0 or v0.0.1 depending on part.
+func (SemVer) Next(latest string, part Part) (string, error) {
+	var v Version
+	if latest == "" {
+		switch part {
+		case Major:
+			v.Major = 1
+		case Patch:
+			v.Patch = 1
+		default:
+			v.Minor = 1
+		}
+		return v.String(), nil
+	}
+	v, err := Parse(latest)
+	if err != nil {
+		return "", err
+	}
+	switch part {
+	case Major:
+		v = v.BumpMajor()
+	case Patch:
+		v = v.BumpPatch()
+	default:
+		v = v.BumpMinor()
+	}
+	return v.String(), nil
+}
+
+// compareValidity orders invalid versions before valid ones
+func compareValidity(aValid, bValid bool) int {
+	switch {
+	case aValid == bValid:
+		return 0
+	case aValid:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// versionFromRef returns the first suffix of the last path element of name which is valid,
+// it has to be the whole element or follow a - _ or @ separated prefix
+func versionFromRef(name string, valid func(string) bool) (string, bool) {
+	last := name[strings.LastIndexByte(name, '/')+1:]
+	for i := 0; i < len(last); i++ {
+		if i > 0 && !strings.ContainsRune("-_@", rune(last[i-1])) {
+			continue
+		}
+		if valid(last[i:]) {
+			return last[i:], true
+		}
+	}
+	return "", false
+}
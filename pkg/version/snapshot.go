@@ -0,0 +1,41 @@
+package version
+
+import (
+	"strings"
+	"time"
+)
+
+// snapshotPreRelease is the pre-release identifier of snapshots, followed by the commit date
+const snapshotPreRelease = "dev"
+
+// snapshotDateLayout is the layout of the commit date of snapshots
+const snapshotDateLayout = "20060102"
+
+// Snapshot returns the snapshot version of an unreleased commit like v1.4.0-dev.20261017+g1a2b3c4.
+// next is the version the commit would be released as, date the commit date and hash the commit hash,
+// which is shortened to 7 characters like git describe does.
+func Snapshot(next Version, date time.Time, hash string) Version {
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+	return Version{
+		Major:      next.Major,
+		Minor:      next.Minor,
+		Patch:      next.Patch,
+		PreRelease: snapshotPreRelease + "." + date.UTC().Format(snapshotDateLayout),
+		Build:      "g" + hash,
+	}
+}
+
+// SnapshotDate returns the commit date of a snapshot version created by Snapshot, false for all other versions
+func SnapshotDate(v Version) (time.Time, bool) {
+	date, ok := strings.CutPrefix(v.PreRelease, snapshotPreRelease+".")
+	if !ok || !strings.HasPrefix(v.Build, "g") {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(snapshotDateLayout, date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,34 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	date := time.Date(2026, time.October, 17, 23, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	v := Snapshot(Version{Major: 1, Minor: 4}, date, "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d")
+
+	assert.Equal(t, "v1.4.0-dev.20261017+g1a2b3c4", v.String())
+	assert.True(t, v.IsPreRelease())
+	assert.Equal(t, -1, Compare(v, Version{Major: 1, Minor: 4}))
+	assert.Equal(t, 1, Compare(v, Version{Major: 1, Minor: 3, Patch: 9}))
+}
+
+func TestSnapshotDate(t *testing.T) {
+	v, err := Parse("v1.4.0-dev.20261017+g1a2b3c4")
+	assert.NoError(t, err)
+	date, ok := SnapshotDate(v)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), date)
+
+	for _, s := range []string{"v1.4.0", "v1.4.0-rc.1", "v1.4.0-dev.20261017", "v1.4.0-dev.2026+g1a2b3c4", "v1.4.0-dev.20261317+g1a2b3c4"} {
+		v, err := Parse(s)
+		assert.NoError(t, err, s)
+		_, ok := SnapshotDate(v)
+		assert.False(t, ok, s)
+	}
+}
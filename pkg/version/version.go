@@ -0,0 +1,200 @@
+// Package version parses and compares semantic versions following SemVer 2.0 like v1.2.3-rc.1+build.5
+// and calendar versions like 2026.10.3, both implement Scheme.
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for strings which aren't a version of the scheme
+var ErrInvalid = errors.New("invalid version")
+
+// Version is a semantic version, the zero value is v0.0.0
+type Version struct {
+	Major, Minor, Patch uint64
+	// PreRelease are the dot separated pre-release identifiers like rc.1, empty for releases
+	PreRelease string
+	// Build is the dot separated build metadata like build.5, it is ignored for precedence
+	Build string
+}
+
+// Parse strictly parses a semantic version with an optional leading v like v1.2.3 or 1.2.3-rc.1+build.5.
+// Short versions like v1.2 and numbers with leading zeros are rejected.
+func Parse(s string) (Version, error) {
+	var v Version
+	rest := strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("%w %q: invalid build metadata", ErrInvalid, s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.PreRelease = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.PreRelease, true) {
+			return Version{}, fmt.Errorf("%w %q: invalid pre-release", ErrInvalid, s)
+		}
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%w %q: expected major.minor.patch", ErrInvalid, s)
+	}
+	numbers := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		if !isNumber(p) {
+			return Version{}, fmt.Errorf("%w %q: %q is not a number without leading zeros", ErrInvalid, s, p)
+		}
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w %q: %w", ErrInvalid, s, err)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// FromRef returns the version at the end of a branch or tag name like release/v1.2.3, app-v1.2.3 or release-1.2.3.
+// The version has to be the whole last path element or follow a - _ or @ separated prefix, so names like
+// feature/v2-migration or feature/v2.0-migration don't contain a version.
+func FromRef(name string) (Version, bool) {
+	s, ok := versionFromRef(name, func(s string) bool {
+		_, err := Parse(s)
+		return err == nil
+	})
+	if !ok {
+		return Version{}, false
+	}
+	v, _ := Parse(s)
+	return v, true
+}
+
+// String returns the version with a leading v, e.g. v1.2.3-rc.1+build.5
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// IsPreRelease reports whether v is a pre-release like v1.2.3-rc.1
+func (v Version) IsPreRelease() bool {
+	return v.PreRelease != ""
+}
+
+// BumpMajor returns the next major release, e.g. v2.0.0 for v1.2.3.
+// The major release of a pre-release like v2.0.0-rc.1 is the release v2.0.0 itself.
+func (v Version) BumpMajor() Version {
+	if !v.IsPreRelease() || v.Minor != 0 || v.Patch != 0 {
+		v.Major++
+	}
+	return Version{Major: v.Major}
+}
+
+// BumpMinor returns the next minor release, e.g. v1.3.0 for v1.2.3.
+// The minor release of a pre-release like v1.3.0-rc.1 is the release v1.3.0 itself.
+func (v Version) BumpMinor() Version {
+	if !v.IsPreRelease() || v.Patch != 0 {
+		v.Minor++
+	}
+	return Version{Major: v.Major, Minor: v.Minor}
+}
+
+// BumpPatch returns the next patch release, e.g. v1.2.4 for v1.2.3.
+// The patch release of a pre-release like v1.2.4-rc.1 is the release v1.2.4 itself.
+func (v Version) BumpPatch() Version {
+	if !v.IsPreRelease() {
+		v.Patch++
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// Compare returns -1, 0 or +1 depending on the precedence of a and b, build metadata is ignored.
+// A pre-release has a lower precedence than the release, e.g. v1.0.0-rc.1 < v1.0.0.
+func Compare(a, b Version) int {
+	for _, c := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if c[0] != c[1] {
+			return compareNumbers(c[0], c[1])
+		}
+	}
+	switch {
+	case a.PreRelease == b.PreRelease:
+		return 0
+	case a.PreRelease == "":
+		return 1
+	case b.PreRelease == "":
+		return -1
+	}
+	ids, others := strings.Split(a.PreRelease, "."), strings.Split(b.PreRelease, ".")
+	for i := 0; i < len(ids) && i < len(others); i++ {
+		if c := compareIdentifiers(ids[i], others[i]); c != 0 {
+			return c
+		}
+	}
+	return compareNumbers(uint64(len(ids)), uint64(len(others)))
+}
+
+// compareIdentifiers compares numeric identifiers numerically, they have a lower precedence than alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	aNum, bNum := isNumber(a), isNumber(b)
+	switch {
+	case aNum && bNum:
+		// the numbers are compared by length first, which avoids overflows
+		if len(a) != len(b) {
+			return compareNumbers(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareNumbers(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// validIdentifiers checks dot separated identifiers of [0-9A-Za-z-],
+// numeric pre-release identifiers must not have leading zeros
+func validIdentifiers(s string, preRelease bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, c := range id {
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+				return false
+			}
+		}
+		if preRelease && len(id) > 1 && id[0] == '0' && strings.Trim(id, "0123456789") == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber reports whether s is a decimal number without leading zeros
+func isNumber(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	return strings.Trim(s, "0123456789") == ""
+}
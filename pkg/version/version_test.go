@@ -0,0 +1,125 @@
+package version
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"v1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}},
+		{"v12.3.4", Version{Major: 12, Minor: 3, Patch: 4}},
+		{"v0.0.0", Version{}},
+		{"v1.0.0-rc.1", Version{Major: 1, PreRelease: "rc.1"}},
+		{"v1.0.0-x-y.0", Version{Major: 1, PreRelease: "x-y.0"}},
+		{"v1.0.0+build.007", Version{Major: 1, Build: "build.007"}},
+		{"v1.0.0-rc.1+build-5", Version{Major: 1, PreRelease: "rc.1", Build: "build-5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, in := range []string{
+		"", "v", "v1", "v1.2", "v1.2.3.4", "v01.2.3", "v1.02.3", "v1.2.03", "vv1.2.3", "V1.2.3",
+		"v1.2.3-", "v1.2.3-rc..1", "v1.2.3-rc.01", "v1.2.3-rc_1", "v1.2.3+", "v1.2.3+build..1",
+		"v-1.2.3", "v1.2.x", "v99999999999999999999.0.0", "release/v1.2.3",
+	} {
+		_, err := Parse(in)
+		assert.ErrorIs(t, err, ErrInvalid, in)
+	}
+}
+
+func TestFromRef(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"v1.2.3", "v1.2.3", true},
+		{"release/v12.3.4", "v12.3.4", true},
+		{"release/v1.2.3-rc.1", "v1.2.3-rc.1", true},
+		{"release-1.2.3", "v1.2.3", true},
+		{"app-v1.2.3", "v1.2.3", true},
+		{"app_v1.2.3", "v1.2.3", true},
+		{"app@1.2.3", "v1.2.3", true},
+		{"1.2.3", "v1.2.3", true},
+		{"feature/v2-migration", "", false},
+		{"feature/v2.0-migration", "", false},
+		{"feature/v1.2.3-migration/x", "", false},
+		{"v1.2.3/notes", "", false},
+		{"appv1.2.3", "", false},
+		{"release/v1.2", "", false},
+		{"main", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromRef(tt.name)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// ordered by precedence, the example of the SemVer 2.0 specification plus multi-digit numbers
+	ordered := []string{
+		"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta", "v1.0.0-beta.2",
+		"v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.0.1", "v1.9.0", "v1.10.0", "v2.0.0", "v10.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, b := mustParse(t, ordered[i]), mustParse(t, ordered[j])
+			assert.Equal(t, compareNumbers(uint64(i), uint64(j)), Compare(a, b), "%s <=> %s", ordered[i], ordered[j])
+		}
+	}
+
+	assert.Equal(t, 0, Compare(mustParse(t, "v1.0.0+build.1"), mustParse(t, "v1.0.0+build.2")))
+
+	versions := []Version{mustParse(t, "v10.0.0"), mustParse(t, "v2.0.0"), mustParse(t, "v2.0.0-rc.1")}
+	sort.Slice(versions, func(i, j int) bool { return Compare(versions[i], versions[j]) < 0 })
+	assert.Equal(t, "v2.0.0-rc.1 v2.0.0 v10.0.0", versions[0].String()+" "+versions[1].String()+" "+versions[2].String())
+}
+
+func TestVersion_Bump(t *testing.T) {
+	tests := []struct {
+		in                  string
+		major, minor, patch string
+	}{
+		{"v1.2.3", "v2.0.0", "v1.3.0", "v1.2.4"},
+		{"v12.3.4+build.1", "v13.0.0", "v12.4.0", "v12.3.5"},
+		{"v2.0.0-rc.1", "v2.0.0", "v2.0.0", "v2.0.0"},
+		{"v1.3.0-rc.1", "v2.0.0", "v1.3.0", "v1.3.0"},
+		{"v1.2.4-rc.1", "v2.0.0", "v1.3.0", "v1.2.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			v := mustParse(t, tt.in)
+			assert.Equal(t, tt.major, v.BumpMajor().String())
+			assert.Equal(t, tt.minor, v.BumpMinor().String())
+			assert.Equal(t, tt.patch, v.BumpPatch().String())
+		})
+	}
+}
+
+func mustParse(t *testing.T, s string) Version {
+	t.Helper()
+	v, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,210 @@
+// Package webhook parses and verifies push and merge webhooks of GitHub, GitLab and Gitea
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Providers
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+	Gitea  = "gitea"
+)
+
+// Kinds of events
+const (
+	Push  = "push"
+	Merge = "merge"
+)
+
+// MaxBodySize limits the size of webhook payloads
+const MaxBodySize = 10 << 20
+
+var (
+	// ErrInvalidSignature is returned if the signature or token of a request doesn't match the secret
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrIgnored is returned for valid events which never trigger a release, e.g. tag pushes or pings
+	ErrIgnored = errors.New("event ignored")
+	// ErrUnknownProvider is returned if the request isn't a GitHub, GitLab or Gitea webhook
+	ErrUnknownProvider = errors.New("unknown webhook provider")
+)
+
+// Event is a push to or a merge into Branch of the repository reachable by RepoURLs
+type Event struct {
+	Provider string
+	Kind     string
+	Branch   string
+	// RepoURLs are the ssh and http urls of the repository
+	RepoURLs []string
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s to %s", e.Provider, e.Kind, e.Branch)
+}
+
+// Parse verifies and parses a webhook request, the provider is detected by the request headers.
+// If secret is empty the signature isn't verified.
+func Parse(r *http.Request, secret string) (Event, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBodySize))
+	if err != nil {
+		return Event{}, err
+	}
+	// Gitea also sends the GitHub headers, so it is checked first
+	switch {
+	case r.Header.Get("X-Gitea-Event") != "":
+		if secret != "" && !validHMAC(secret, body, r.Header.Get("X-Gitea-Signature")) {
+			return Event{}, ErrInvalidSignature
+		}
+		return parseGitHub(Gitea, r.Header.Get("X-Gitea-Event"), body)
+	case r.Header.Get("X-GitHub-Event") != "":
+		if secret != "" && !validHMAC(secret, body, strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")) {
+			return Event{}, ErrInvalidSignature
+		}
+		return parseGitHub(GitHub, r.Header.Get("X-GitHub-Event"), body)
+	case r.Header.Get("X-Gitlab-Event") != "":
+		if secret != "" && !hmac.Equal([]byte(secret), []byte(r.Header.Get("X-Gitlab-Token"))) {
+			return Event{}, ErrInvalidSignature
+		}
+		return parseGitLab(r.Header.Get("X-Gitlab-Event"), body)
+	}
+	return Event{}, ErrUnknownProvider
+}
+
+func validHMAC(secret string, body []byte, signature string) bool {
+	want, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+type githubRepository struct {
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+type githubPayload struct {
+	Ref         string           `json:"ref"`
+	Deleted     bool             `json:"deleted"`
+	Action      string           `json:"action"`
+	Repository  githubRepository `json:"repository"`
+	PullRequest struct {
+		Merged bool `json:"merged"`
+		Base   struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+// parseGitHub parses the events of GitHub and Gitea, which share the same payloads
+func parseGitHub(provider, event string, body []byte) (Event, error) {
+	var p githubPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Event{}, fmt.Errorf("invalid %s payload: %w", provider, err)
+	}
+	e := Event{Provider: provider, RepoURLs: nonEmpty(p.Repository.SSHURL, p.Repository.CloneURL)}
+	switch event {
+	case "push":
+		if p.Deleted {
+			return e, fmt.Errorf("%w: branch deleted", ErrIgnored)
+		}
+		return pushEvent(e, p.Ref)
+	case "pull_request":
+		if p.Action != "closed" || !p.PullRequest.Merged {
+			return e, fmt.Errorf("%w: pull request %s", ErrIgnored, p.Action)
+		}
+		e.Kind, e.Branch = Merge, p.PullRequest.Base.Ref
+		return e, nil
+	}
+	return e, fmt.Errorf("%w: %s event", ErrIgnored, event)
+}
+
+type gitlabPayload struct {
+	Ref     string `json:"ref"`
+	After   string `json:"after"`
+	Project struct {
+		SSHURL  string `json:"git_ssh_url"`
+		HTTPURL string `json:"git_http_url"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		Action       string `json:"action"`
+		TargetBranch string `json:"target_branch"`
+	} `json:"object_attributes"`
+}
+
+var zeroHash = regexp.MustCompile(`^0+$`)
+
+func parseGitLab(event string, body []byte) (Event, error) {
+	var p gitlabPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Event{}, fmt.Errorf("invalid gitlab payload: %w", err)
+	}
+	e := Event{Provider: GitLab, RepoURLs: nonEmpty(p.Project.SSHURL, p.Project.HTTPURL)}
+	switch event {
+	case "Push Hook":
+		if zeroHash.MatchString(p.After) {
+			return e, fmt.Errorf("%w: branch deleted", ErrIgnored)
+		}
+		return pushEvent(e, p.Ref)
+	case "Merge Request Hook":
+		if p.ObjectAttributes.Action != "merge" {
+			return e, fmt.Errorf("%w: merge request %s", ErrIgnored, p.ObjectAttributes.Action)
+		}
+		e.Kind, e.Branch = Merge, p.ObjectAttributes.TargetBranch
+		return e, nil
+	}
+	return e, fmt.Errorf("%w: %s", ErrIgnored, event)
+}
+
+func pushEvent(e Event, ref string) (Event, error) {
+	// tags and release branches pushed by git-releaser itself must not trigger another release,
+	// tags are ignored here, release branches don't match the source branch of any repo
+	if !strings.HasPrefix(ref, "refs/heads/") {
+		return e, fmt.Errorf("%w: push to %s", ErrIgnored, ref)
+	}
+	e.Kind, e.Branch = Push, strings.TrimPrefix(ref, "refs/heads/")
+	return e, nil
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+var (
+	scpLikeURL = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+	schemeURL  = regexp.MustCompile(`^[a-z+]+://(?:[^@/]+@)?([^/:]+)(?::\d+)?/(.+)$`)
+)
+
+// RepoKey normalizes a repository url, so that ssh and http urls of the same repository are equal,
+// e.g. git@github.com:acme/api.git and https://github.com/acme/api become github.com/acme/api
+func RepoKey(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	var host, path string
+	if m := schemeURL.FindStringSubmatch(url); m != nil {
+		host, path = m[1], m[2]
+	} else if m := scpLikeURL.FindStringSubmatch(url); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return url
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/")
+	return host + "/" + path
+}